@@ -0,0 +1,64 @@
+// Command iforest-serve serves a model saved by Forest.Save over HTTP.
+//
+// Endpoints:
+//
+//	POST /score    anomaly scores of the vectors of the request
+//	POST /predict  labels (1 for anomalies) and scores of the vectors
+//	GET  /healthz  liveness of the server
+//	GET  /model    parameters of the served model
+//	POST /reload   loads the model file again
+//
+// Vectors are sent as a JSON object {"data": [[...], ...]} or as CSV records
+// with the text/csv content type, optionally preceded by a header. Responses
+// are JSON objects {"labels": [...], "scores": [...]}.
+//
+// The model is swapped without dropping requests when it is reloaded, either
+// explicitly or when the file changes with -reload-interval. A model with
+// another number of features is refused and the current one kept.
+//
+// Usage:
+//
+//	iforest-serve -model model.json [-addr :8080] [-max-body 10485760] [-workers 4] [-reload-interval 1m]
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"net/http"
+	"runtime"
+	"time"
+
+	"github.com/e-XpertSolutions/go-iforest/v2/iforest"
+)
+
+func main() {
+	modelPath := flag.String("model", "", "path of the model saved by Forest.Save")
+	addr := flag.String("addr", ":8080", "address to listen on")
+	maxBody := flag.Int64("max-body", 10<<20, "maximum size of request bodies in bytes")
+	workers := flag.Int("workers", runtime.NumCPU(), "number of go routines scoring a request")
+	reloadInterval := flag.Duration("reload-interval", 0, "interval between checks of the model file for changes, 0 to disable")
+	flag.Parse()
+
+	if *modelPath == "" {
+		log.Fatal("missing -model")
+	}
+	h, err := iforest.NewHolder(*modelPath)
+	if err != nil {
+		log.Fatalf("cannot load model: %v", err)
+	}
+	if *reloadInterval > 0 {
+		go h.Watch(context.Background(), *reloadInterval, func(err error) {
+			log.Printf("cannot reload model: %v", err)
+		})
+	}
+	s := newServer(h, *maxBody, *workers)
+
+	srv := &http.Server{
+		Addr:              *addr,
+		Handler:           s.routes(),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	log.Printf("serving %s on %s", *modelPath, *addr)
+	log.Fatal(srv.ListenAndServe())
+}
@@ -0,0 +1,227 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/e-XpertSolutions/go-iforest/v2/iforest"
+	"github.com/e-XpertSolutions/go-iforest/v2/iforest/dataio"
+)
+
+// server scores vectors with the model of a holder.
+type server struct {
+	holder *iforest.Holder
+	// maxBody limits the size of request bodies, in bytes.
+	maxBody int64
+	// workers is the number of go routines scoring a request.
+	workers int
+}
+
+// newServer returns a server for the model of the holder.
+func newServer(h *iforest.Holder, maxBody int64, workers int) *server {
+	if workers < 1 {
+		workers = 1
+	}
+	return &server{holder: h, maxBody: maxBody, workers: workers}
+}
+
+// routes returns the handler of the server.
+func (s *server) routes() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/score", s.handlePredict(false))
+	mux.HandleFunc("/predict", s.handlePredict(true))
+	mux.HandleFunc("/healthz", s.handleHealth)
+	mux.HandleFunc("/model", s.handleModel)
+	mux.HandleFunc("/reload", s.handleReload)
+	return mux
+}
+
+// request is the JSON body of scoring requests.
+type request struct {
+	Data [][]float64 `json:"data"`
+}
+
+// scoreResponse is the JSON body of /score responses.
+type scoreResponse struct {
+	Scores []float64 `json:"scores"`
+}
+
+// predictResponse is the JSON body of /predict responses.
+type predictResponse struct {
+	Labels []int     `json:"labels"`
+	Scores []float64 `json:"scores"`
+}
+
+// metadata describes the served model.
+type metadata struct {
+	Path            string              `json:"path"`
+	NbTrees         int                 `json:"trees"`
+	SubsamplingSize int                 `json:"subsampling_size"`
+	HeightLimit     int                 `json:"height_limit"`
+	Features        int                 `json:"features"`
+	AnomalyBound    float64             `json:"anomaly_bound"`
+	AnomalyRatio    float64             `json:"anomaly_ratio"`
+	Aggregation     iforest.Aggregation `json:"aggregation,omitempty"`
+}
+
+func (s *server) handlePredict(withLabels bool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.Header().Set("Allow", http.MethodPost)
+			writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+			return
+		}
+		r.Body = http.MaxBytesReader(w, r.Body, s.maxBody)
+		X, err := s.readData(r)
+		if err != nil {
+			var tooLarge *http.MaxBytesError
+			if errors.As(err, &tooLarge) {
+				writeError(w, http.StatusRequestEntityTooLarge, "request body too large")
+				return
+			}
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		var labels []int
+		var scores []float64
+		if len(X) > 0 {
+			routines := s.workers
+			if routines > len(X) {
+				routines = len(X)
+			}
+			labels, scores, err = s.holder.PredictParallel(X, routines)
+			if err != nil {
+				writeError(w, http.StatusInternalServerError, err.Error())
+				return
+			}
+		}
+
+		if scores == nil {
+			labels, scores = []int{}, []float64{}
+		}
+		if withLabels {
+			writeJSON(w, http.StatusOK, predictResponse{Labels: labels, Scores: scores})
+		} else {
+			writeJSON(w, http.StatusOK, scoreResponse{Scores: scores})
+		}
+	}
+}
+
+// readData decodes the vectors of the request, sent as JSON or as CSV.
+func (s *server) readData(r *http.Request) ([][]float64, error) {
+	var X [][]float64
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	switch mediaType {
+	case "text/csv":
+		var err error
+		if X, err = readCSV(r.Body); err != nil {
+			return nil, err
+		}
+	case "application/json", "":
+		var req request
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			return nil, fmt.Errorf("invalid JSON body: %w", err)
+		}
+		X = req.Data
+	default:
+		return nil, fmt.Errorf("unsupported content type %q", mediaType)
+	}
+
+	features := s.holder.Features()
+	for i, x := range X {
+		if len(x) != features {
+			return nil, fmt.Errorf("vector %d has %d features, want %d", i, len(x), features)
+		}
+	}
+	return X, nil
+}
+
+// readCSV reads vectors from CSV records. A first record in which no field is
+// a number is a header and is skipped.
+func readCSV(body io.Reader) ([][]float64, error) {
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return nil, fmt.Errorf("invalid CSV body: %w", err)
+	}
+	if len(bytes.TrimSpace(data)) == 0 {
+		return nil, nil
+	}
+	first, err := csv.NewReader(bytes.NewReader(data)).Read()
+	if err != nil {
+		return nil, fmt.Errorf("invalid CSV body: %w", err)
+	}
+	header := true
+	for _, field := range first {
+		if _, err := strconv.ParseFloat(strings.TrimSpace(field), 64); err == nil {
+			header = false
+		}
+	}
+	// missing and non-finite values cannot be scored, they are errors
+	d, err := dataio.ReadCSV(bytes.NewReader(data), dataio.Options{Missing: []string{}, NoHeader: !header})
+	if err != nil {
+		return nil, fmt.Errorf("invalid CSV body: %w", err)
+	}
+	skipped := 0
+	if header {
+		skipped = 1
+	}
+	for i, x := range d.X {
+		for j, v := range x {
+			if math.IsNaN(v) || math.IsInf(v, 0) {
+				return nil, fmt.Errorf("invalid CSV record %d, column %s: %v is not a finite number", i+1+skipped, d.Columns[j], v)
+			}
+		}
+	}
+	return d.X, nil
+}
+
+func (s *server) handleHealth(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+}
+
+func (s *server) handleModel(w http.ResponseWriter, r *http.Request) {
+	f := s.holder.Forest()
+	writeJSON(w, http.StatusOK, metadata{
+		Path:            s.holder.Path(),
+		NbTrees:         len(f.Trees),
+		SubsamplingSize: f.SubsamplingSize,
+		HeightLimit:     f.HeightLimit,
+		Features:        s.holder.Features(),
+		AnomalyBound:    f.AnomalyBound,
+		AnomalyRatio:    f.AnomalyRatio,
+		Aggregation:     f.Aggregation,
+	})
+}
+
+func (s *server) handleReload(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	if err := s.holder.Reload(); err != nil {
+		writeError(w, http.StatusConflict, err.Error())
+		return
+	}
+	s.handleModel(w, r)
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, msg string) {
+	writeJSON(w, status, map[string]string{"error": msg})
+}
@@ -0,0 +1,164 @@
+package main
+
+import (
+	"encoding/json"
+	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/e-XpertSolutions/go-iforest/v2/iforest"
+)
+
+func testForest(t *testing.T) (*iforest.Forest, [][]float64) {
+	rnd := rand.New(rand.NewSource(1))
+	X := make([][]float64, 200)
+	for i := range X {
+		X[i] = []float64{rnd.NormFloat64(), rnd.NormFloat64()}
+		if i%50 == 0 {
+			X[i][0] += 10
+		}
+	}
+	f := iforest.NewForest(50, 64, 0.02)
+	f.Seed = 1
+	f.Train(X)
+	if err := f.Test(X); err != nil {
+		t.Fatalf("Forest.Test() error = %v", err)
+	}
+	return f, X
+}
+
+// testServer saves the forest in a temporary file and serves it.
+func testServer(t *testing.T, f *iforest.Forest, maxBody int64, workers int) (*server, string) {
+	path := filepath.Join(t.TempDir(), "model.json")
+	if err := f.Save(path); err != nil {
+		t.Fatalf("Forest.Save() error = %v", err)
+	}
+	h, err := iforest.NewHolder(path)
+	if err != nil {
+		t.Fatalf("iforest.NewHolder() error = %v", err)
+	}
+	return newServer(h, maxBody, workers), path
+}
+
+func TestServer_predict(t *testing.T) {
+	f, X := testForest(t)
+	s, _ := testServer(t, f, 1<<10, 3)
+	h := s.routes()
+	wantLabels, wantScores, _ := f.Predict(X[:5])
+
+	tests := []struct {
+		name        string
+		method      string
+		path        string
+		contentType string
+		body        string
+		wantStatus  int
+		wantLabels  []int
+		wantScores  []float64
+	}{
+		{name: "json", method: "POST", path: "/predict", contentType: "application/json", body: `{"data": [[` + row(X[0]) + `], [` + row(X[1]) + `], [` + row(X[2]) + `], [` + row(X[3]) + `], [` + row(X[4]) + `]]}`, wantStatus: 200, wantLabels: wantLabels, wantScores: wantScores},
+		{name: "score", method: "POST", path: "/score", body: `{"data": [[` + row(X[0]) + `]]}`, wantStatus: 200, wantScores: wantScores[:1]},
+		{name: "csv", method: "POST", path: "/predict", contentType: "text/csv", body: "a,b\n" + row(X[0]) + "\n" + row(X[1]) + "\n", wantStatus: 200, wantLabels: wantLabels[:2], wantScores: wantScores[:2]},
+		{name: "csv without header", method: "POST", path: "/score", contentType: "text/csv; charset=utf-8", body: row(X[2]) + "\n", wantStatus: 200, wantScores: wantScores[2:3]},
+		{name: "empty", method: "POST", path: "/predict", body: `{"data": []}`, wantStatus: 200, wantLabels: []int{}, wantScores: []float64{}},
+		{name: "wrong method", method: "GET", path: "/predict", wantStatus: 405},
+		{name: "invalid json", method: "POST", path: "/predict", body: `{"data": [[1, 2]`, wantStatus: 400},
+		{name: "invalid csv", method: "POST", path: "/predict", contentType: "text/csv", body: "1,2\n1,x\n", wantStatus: 400},
+		{name: "invalid first csv record", method: "POST", path: "/predict", contentType: "text/csv", body: "1,x\n2,3\n", wantStatus: 400},
+		{name: "csv not a finite number", method: "POST", path: "/predict", contentType: "text/csv", body: "a,b\n1,2\nInf,NaN\n", wantStatus: 400},
+		{name: "csv missing value", method: "POST", path: "/predict", contentType: "text/csv", body: "a,b\n1,\n", wantStatus: 400},
+		{name: "features", method: "POST", path: "/predict", body: `{"data": [[1, 2, 3]]}`, wantStatus: 400},
+		{name: "content type", method: "POST", path: "/predict", contentType: "text/plain", body: "1 2", wantStatus: 400},
+		{name: "too large", method: "POST", path: "/predict", body: `{"data": [` + strings.Repeat("[1, 2], ", 200) + `[1, 2]]}`, wantStatus: 413},
+	}
+	for _, tt := range tests {
+
+		req := httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+		if tt.contentType != "" {
+			req.Header.Set("Content-Type", tt.contentType)
+		}
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		if rec.Code != tt.wantStatus {
+			t.Errorf("%s: status = %v, want %v (%s)", tt.name, rec.Code, tt.wantStatus, rec.Body)
+			continue
+		}
+		if tt.wantStatus != 200 {
+			continue
+		}
+		var resp predictResponse
+		if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+			t.Fatalf("%s: invalid response: %v", tt.name, err)
+		}
+		if !reflect.DeepEqual(resp.Labels, tt.wantLabels) || !reflect.DeepEqual(resp.Scores, tt.wantScores) {
+			t.Errorf("%s: response = %v, %v, want %v, %v", tt.name, resp.Labels, resp.Scores, tt.wantLabels, tt.wantScores)
+		}
+
+	}
+}
+
+func row(x []float64) string {
+	b, _ := json.Marshal(x)
+	return strings.Trim(string(b), "[]")
+}
+
+func TestServer_metadata(t *testing.T) {
+	f, _ := testForest(t)
+	s, path := testServer(t, f, 1<<10, 1)
+	h := s.routes()
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest("GET", "/healthz", nil))
+	if rec.Code != http.StatusOK {
+		t.Errorf("/healthz status = %v", rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest("GET", "/model", nil))
+	var m metadata
+	if err := json.NewDecoder(rec.Body).Decode(&m); err != nil {
+		t.Fatalf("/model invalid response: %v", err)
+	}
+	want := metadata{Path: path, NbTrees: 50, SubsamplingSize: 64, HeightLimit: 6, Features: 2, AnomalyBound: f.AnomalyBound, AnomalyRatio: 0.02}
+	if m != want {
+		t.Errorf("/model = %+v, want %+v", m, want)
+	}
+}
+
+func TestServer_reload(t *testing.T) {
+	f, X := testForest(t)
+	s, path := testServer(t, f, 1<<10, 1)
+	h := s.routes()
+
+	g := iforest.NewForest(20, 64, 0.02)
+	g.Train(X)
+	g.Test(X)
+	g.Save(path)
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest("POST", "/reload", nil))
+	var m metadata
+	json.NewDecoder(rec.Body).Decode(&m)
+	if rec.Code != http.StatusOK || m.NbTrees != 20 {
+		t.Errorf("/reload status = %v with %v trees, want 200 with 20", rec.Code, m.NbTrees)
+	}
+
+	wide := iforest.NewForest(10, 64, 0.02)
+	wide.Train([][]float64{{1, 2, 3}, {4, 5, 6}, {7, 8, 9}})
+	wide.Test([][]float64{{1, 2, 3}, {4, 5, 6}, {7, 8, 9}})
+	wide.Save(path)
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest("POST", "/reload", nil))
+	if rec.Code != http.StatusConflict || len(s.holder.Forest().Trees) != 20 {
+		t.Errorf("/reload with another number of features status = %v, %v trees served", rec.Code, len(s.holder.Forest().Trees))
+	}
+
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest("GET", "/reload", nil))
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("GET /reload status = %v", rec.Code)
+	}
+}
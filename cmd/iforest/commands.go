@@ -0,0 +1,394 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/e-XpertSolutions/go-iforest/v2/iforest"
+	"github.com/e-XpertSolutions/go-iforest/v2/iforest/eval"
+)
+
+// errUsage is returned when the flags of a command are invalid, the flag
+// package has already reported it.
+var errUsage = errors.New("invalid usage")
+
+// inputFlags are the flags reading a data file.
+type inputFlags struct {
+	in      string
+	header  bool
+	sep     string
+	columns string
+	id      string
+}
+
+func (f *inputFlags) register(fs *flag.FlagSet) {
+	fs.StringVar(&f.in, "in", "-", "data file, - for the standard input")
+	fs.BoolVar(&f.header, "header", true, "the first record names the columns")
+	fs.StringVar(&f.sep, "sep", "", `field separator, "," or tab for .tsv files by default`)
+	fs.StringVar(&f.columns, "columns", "", "comma separated feature columns, all but the ID and label ones by default")
+	fs.StringVar(&f.id, "id", "", "ID column copied to the output")
+}
+
+// read reads the data file. Without -columns, the features of the schema, if
+// any, are read by name from files with a header.
+func (f *inputFlags) read(stdin io.Reader, label string, schema *iforest.Schema) (*dataset, error) {
+	opts := readOptions{Header: f.header, ID: f.id, Label: label}
+	switch f.sep {
+	case "":
+	case `\t`, "tab":
+		opts.Sep = '\t'
+	default:
+		if len([]rune(f.sep)) != 1 {
+			return nil, fmt.Errorf("invalid separator %q", f.sep)
+		}
+		opts.Sep = []rune(f.sep)[0]
+	}
+	if f.columns != "" {
+		opts.Columns = strings.Split(f.columns, ",")
+	} else if schema != nil && f.header {
+		opts.Columns = schema.Names()
+	}
+	return readFile(f.in, stdin, opts)
+}
+
+// outputFlags are the flags writing scores.
+type outputFlags struct {
+	out    string
+	format string
+}
+
+func (f *outputFlags) register(fs *flag.FlagSet) {
+	fs.StringVar(&f.out, "out", "-", "output file, - for the standard output")
+	fs.StringVar(&f.format, "format", "csv", "output format, csv or jsonl")
+}
+
+// write writes the ID, if any, the label and the score of each row.
+func (f *outputFlags) write(stdout io.Writer, d *dataset, labels []int, scores []float64) (err error) {
+	if f.format != "csv" && f.format != "jsonl" {
+		return fmt.Errorf("unknown format %q", f.format)
+	}
+	w := stdout
+	if f.out != "-" {
+		file, err := os.Create(f.out)
+		if err != nil {
+			return err
+		}
+		defer func() {
+			if cerr := file.Close(); err == nil {
+				err = cerr
+			}
+		}()
+		w = file
+	}
+
+	if f.format == "jsonl" {
+		enc := json.NewEncoder(w)
+		for i := range scores {
+			row := struct {
+				ID    string  `json:"id,omitempty"`
+				Label int     `json:"label"`
+				Score float64 `json:"score"`
+			}{Label: labels[i], Score: scores[i]}
+			if d.IDs != nil {
+				row.ID = d.IDs[i]
+			}
+			if err := enc.Encode(row); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	var b strings.Builder
+	if d.IDs != nil {
+		b.WriteString("id,")
+	}
+	b.WriteString("label,score\n")
+	for i := range scores {
+		if d.IDs != nil {
+			b.WriteString(csvField(d.IDs[i]))
+			b.WriteByte(',')
+		}
+		b.WriteString(strconv.Itoa(labels[i]))
+		b.WriteByte(',')
+		b.WriteString(strconv.FormatFloat(scores[i], 'g', -1, 64))
+		b.WriteByte('\n')
+	}
+	_, err = io.WriteString(w, b.String())
+	return err
+}
+
+// csvField quotes a field when needed.
+func csvField(s string) string {
+	if strings.ContainsAny(s, ",\"\r\n") {
+		return `"` + strings.ReplaceAll(s, `"`, `""`) + `"`
+	}
+	return s
+}
+
+func newFlagSet(name string, stderr io.Writer) *flag.FlagSet {
+	fs := flag.NewFlagSet("iforest "+name, flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	return fs
+}
+
+func parse(fs *flag.FlagSet, args []string) error {
+	if err := fs.Parse(args); err != nil {
+		return errUsage
+	}
+	if fs.NArg() > 0 {
+		fmt.Fprintf(fs.Output(), "unexpected arguments %v\n", fs.Args())
+		return errUsage
+	}
+	return nil
+}
+
+func loadModel(path string) (*iforest.Forest, error) {
+	if path == "" {
+		return nil, errors.New("missing -model")
+	}
+	f := &iforest.Forest{}
+	if err := f.Load(path); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// checkFeatures verifies that the data has as many features as the model.
+func checkFeatures(f *iforest.Forest, d *dataset) error {
+	if f.NbFeatures != 0 && len(d.Features) != f.NbFeatures {
+		return fmt.Errorf("data has %d features, model has %d", len(d.Features), f.NbFeatures)
+	}
+	return nil
+}
+
+func trainCmd(args []string, stdin io.Reader, stdout, stderr io.Writer) error {
+	fs := newFlagSet("train", stderr)
+	var in inputFlags
+	in.register(fs)
+	model := fs.String("model", "", "path of the saved model")
+	trees := fs.Int("trees", 100, "number of trees")
+	subsample := fs.Int("subsample", 256, "subsampling size")
+	ratio := fs.Float64("ratio", 0.01, "expected ratio of anomalies")
+	seed := fs.Int64("seed", 0, "seed of the training, random when 0")
+	if err := parse(fs, args); err != nil {
+		return err
+	}
+	if *model == "" {
+		return errors.New("missing -model")
+	}
+	if *trees < 1 || *subsample < 2 || *ratio < 0 || *ratio > 1 {
+		return errors.New("invalid parameters")
+	}
+
+	d, err := in.read(stdin, "", nil)
+	if err != nil {
+		return err
+	}
+	f := iforest.NewForest(*trees, *subsample, *ratio)
+	f.Seed = *seed
+	if f.Schema, err = iforest.NewSchema(d.Features, d.X); err != nil {
+		return err
+	}
+	f.Train(d.X)
+	if err := f.Test(d.X); err != nil {
+		return err
+	}
+	if err := f.Save(*model); err != nil {
+		return err
+	}
+	fmt.Fprintf(stderr, "trained %d trees on %d rows, anomaly bound %g\n", len(f.Trees), len(d.X), f.AnomalyBound)
+	return nil
+}
+
+func testCmd(args []string, stdin io.Reader, stdout, stderr io.Writer) error {
+	fs := newFlagSet("test", stderr)
+	var in inputFlags
+	in.register(fs)
+	var out outputFlags
+	out.register(fs)
+	model := fs.String("model", "", "path of the model, updated with the new bound")
+	if err := parse(fs, args); err != nil {
+		return err
+	}
+
+	f, err := loadModel(*model)
+	if err != nil {
+		return err
+	}
+	d, err := in.read(stdin, "", f.Schema)
+	if err != nil {
+		return err
+	}
+	if err := checkFeatures(f, d); err != nil {
+		return err
+	}
+	if err := f.Test(d.X); err != nil {
+		return err
+	}
+	if err := f.Save(*model); err != nil {
+		return err
+	}
+	scores := make([]float64, len(d.X))
+	for i := range scores {
+		scores[i] = f.AnomalyScores[i]
+	}
+	return out.write(stdout, d, f.Labels, scores)
+}
+
+func predictCmd(args []string, stdin io.Reader, stdout, stderr io.Writer) error {
+	fs := newFlagSet("predict", stderr)
+	var in inputFlags
+	in.register(fs)
+	var out outputFlags
+	out.register(fs)
+	model := fs.String("model", "", "path of the model")
+	if err := parse(fs, args); err != nil {
+		return err
+	}
+
+	f, err := loadModel(*model)
+	if err != nil {
+		return err
+	}
+	d, err := in.read(stdin, "", f.Schema)
+	if err != nil {
+		return err
+	}
+	if err := checkFeatures(f, d); err != nil {
+		return err
+	}
+	labels, scores, err := f.Predict(d.X)
+	if err != nil {
+		return err
+	}
+	return out.write(stdout, d, labels, scores)
+}
+
+func inspectCmd(args []string, stdin io.Reader, stdout, stderr io.Writer) error {
+	fs := newFlagSet("inspect", stderr)
+	model := fs.String("model", "", "path of the model")
+	if err := parse(fs, args); err != nil {
+		return err
+	}
+
+	f, err := loadModel(*model)
+	if err != nil {
+		return err
+	}
+	var nodes, depth int
+	for _, t := range f.Trees {
+		n, d := treeSize(t.Root, 0)
+		nodes += n
+		if d > depth {
+			depth = d
+		}
+	}
+
+	w := tabwriter.NewWriter(stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "trees\t%d\n", len(f.Trees))
+	fmt.Fprintf(w, "nodes\t%d\n", nodes)
+	fmt.Fprintf(w, "max depth\t%d\n", depth)
+	fmt.Fprintf(w, "features\t%d\n", f.NbFeatures)
+	if f.Schema != nil {
+		fmt.Fprintf(w, "feature names\t%s\n", strings.Join(f.Schema.Names(), ","))
+	}
+	fmt.Fprintf(w, "subsampling size\t%d\n", f.SubsamplingSize)
+	fmt.Fprintf(w, "height limit\t%d\n", f.HeightLimit)
+	fmt.Fprintf(w, "anomaly ratio\t%g\n", f.AnomalyRatio)
+	fmt.Fprintf(w, "anomaly bound\t%g\n", f.AnomalyBound)
+	aggregation := string(f.Aggregation)
+	if aggregation == "" {
+		aggregation = "mean"
+	}
+	fmt.Fprintf(w, "aggregation\t%s\n", aggregation)
+	fmt.Fprintf(w, "trained\t%v\n", f.Trained)
+	fmt.Fprintf(w, "tested\t%v\n", f.Tested)
+	return w.Flush()
+}
+
+// treeSize returns the number of nodes and the depth of a tree.
+func treeSize(n *iforest.Node, depth int) (int, int) {
+	if n == nil {
+		return 0, depth
+	}
+	if n.External {
+		return 1, depth
+	}
+	ln, ld := treeSize(n.Left, depth+1)
+	rn, rd := treeSize(n.Right, depth+1)
+	if rd > ld {
+		ld = rd
+	}
+	return 1 + ln + rn, ld
+}
+
+func evalCmd(args []string, stdin io.Reader, stdout, stderr io.Writer) error {
+	fs := newFlagSet("eval", stderr)
+	var in inputFlags
+	in.register(fs)
+	model := fs.String("model", "", "path of the model")
+	label := fs.String("label", "", "label column, 1 for anomalies and 0 for normal rows")
+	k := fs.Int("k", 0, "rank of the precision at k, the number of anomalies by default")
+	if err := parse(fs, args); err != nil {
+		return err
+	}
+	if *label == "" {
+		return errors.New("missing -label")
+	}
+
+	f, err := loadModel(*model)
+	if err != nil {
+		return err
+	}
+	d, err := in.read(stdin, *label, f.Schema)
+	if err != nil {
+		return err
+	}
+	if err := checkFeatures(f, d); err != nil {
+		return err
+	}
+	_, scores, err := f.Predict(d.X)
+	if err != nil {
+		return err
+	}
+
+	auc, err := eval.AUC(scores, d.Labels)
+	if err != nil {
+		return err
+	}
+	ap, err := eval.AveragePrecision(scores, d.Labels)
+	if err != nil {
+		return err
+	}
+	c, err := eval.Confusion(scores, d.Labels, f.AnomalyBound)
+	if err != nil {
+		return err
+	}
+	if *k <= 0 {
+		*k = c.TP + c.FN
+	}
+	atK, err := eval.PrecisionAtK(scores, d.Labels, *k)
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "rows\t%d\n", len(scores))
+	fmt.Fprintf(w, "anomalies\t%d\n", c.TP+c.FN)
+	fmt.Fprintf(w, "roc auc\t%.4f\n", auc)
+	fmt.Fprintf(w, "average precision\t%.4f\n", ap)
+	fmt.Fprintf(w, "precision@%d\t%.4f\n", *k, atK)
+	fmt.Fprintf(w, "precision\t%.4f\n", c.Precision())
+	fmt.Fprintf(w, "recall\t%.4f\n", c.Recall())
+	fmt.Fprintf(w, "f1\t%.4f\n", c.F1())
+	fmt.Fprintf(w, "confusion\ttp=%d fp=%d tn=%d fn=%d\n", c.TP, c.FP, c.TN, c.FN)
+	return w.Flush()
+}
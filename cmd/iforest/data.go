@@ -0,0 +1,116 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/e-XpertSolutions/go-iforest/v2/iforest/dataio"
+)
+
+// dataset is a table read from a CSV or TSV file.
+type dataset struct {
+	// Features are the names of the feature columns.
+	Features []string
+	X        [][]float64
+	// IDs and Labels hold the values of the ID and of the label columns, when
+	// they were asked for.
+	IDs    []string
+	Labels []int
+}
+
+// readOptions selects the columns read from a file.
+type readOptions struct {
+	// Sep is the field separator, guessed from the file extension when 0.
+	Sep rune
+	// Header tells whether the first record names the columns.
+	Header bool
+	// Columns are the feature columns, by name with a header or by 1-based
+	// index otherwise. All remaining columns are features when empty.
+	Columns []string
+	// ID and Label are the ID and the label columns, if any.
+	ID    string
+	Label string
+}
+
+// readFile reads a dataset from given file, "-" standing for the standard
+// input.
+func readFile(path string, stdin io.Reader, opts readOptions) (*dataset, error) {
+	if opts.Sep == 0 {
+		opts.Sep = ','
+		if strings.HasSuffix(path, ".tsv") {
+			opts.Sep = '\t'
+		}
+	}
+	if path == "-" {
+		return readData(stdin, opts)
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return readData(file, opts)
+}
+
+// readData reads a dataset from CSV records.
+func readData(r io.Reader, opts readOptions) (*dataset, error) {
+	var keys []string
+	if opts.ID != "" {
+		keys = append(keys, opts.ID)
+	}
+	if opts.Label != "" {
+		keys = append(keys, opts.Label)
+	}
+	// forests do not handle missing or non-finite values, they are errors
+	it, err := dataio.NewCSVIterator(r, dataio.Options{
+		Columns:  opts.Columns,
+		Keys:     keys,
+		Missing:  []string{},
+		Comma:    opts.Sep,
+		NoHeader: !opts.Header,
+	})
+	if err != nil {
+		return nil, err
+	}
+	d := &dataset{Features: it.Columns()}
+	if len(d.Features) == 0 {
+		return nil, fmt.Errorf("no feature column")
+	}
+
+	line := 1
+	if !opts.Header {
+		line = 0
+	}
+	for it.Next() {
+		line++
+		x := it.Row()
+		for k, v := range x {
+			if math.IsNaN(v) || math.IsInf(v, 0) {
+				return nil, fmt.Errorf("line %d, column %s: %v is not a finite number", line, d.Features[k], v)
+			}
+		}
+		d.X = append(d.X, x)
+		keys := it.Keys()
+		if opts.ID != "" {
+			d.IDs = append(d.IDs, keys[0])
+		}
+		if opts.Label != "" {
+			l, err := strconv.Atoi(strings.TrimSpace(keys[len(keys)-1]))
+			if err != nil || (l != 0 && l != 1) {
+				return nil, fmt.Errorf("line %d, column %s: labels must be 0 or 1", line, opts.Label)
+			}
+			d.Labels = append(d.Labels, l)
+		}
+	}
+	if err := it.Err(); err != nil {
+		return nil, err
+	}
+	if len(d.X) == 0 {
+		return nil, fmt.Errorf("no data")
+	}
+	return d, nil
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestReadData(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		opts    readOptions
+		want    *dataset
+		wantErr bool
+	}{
+		{
+			name: "header",
+			data: "a,b,c\n1,2,3\n4,5,6\n",
+			opts: readOptions{Sep: ',', Header: true},
+			want: &dataset{Features: []string{"a", "b", "c"}, X: [][]float64{{1, 2, 3}, {4, 5, 6}}},
+		},
+		{
+			name: "no header",
+			data: "1,2,3\n4,5,6\n",
+			opts: readOptions{Sep: ',', Columns: []string{"3", "1"}},
+			want: &dataset{Features: []string{"3", "1"}, X: [][]float64{{3, 1}, {6, 4}}},
+		},
+		{
+			name: "id and label",
+			data: "id\tx\ty\tclass\nr1\t1\t2\t0\nr2\t3\t4\t1\n",
+			opts: readOptions{Sep: '\t', Header: true, ID: "id", Label: "class"},
+			want: &dataset{Features: []string{"x", "y"}, X: [][]float64{{1, 2}, {3, 4}}, IDs: []string{"r1", "r2"}, Labels: []int{0, 1}},
+		},
+		{
+			name: "selected columns",
+			data: "id,x,y,z\nr1,1,2,3\n",
+			opts: readOptions{Sep: ',', Header: true, ID: "id", Columns: []string{"z", "x"}},
+			want: &dataset{Features: []string{"z", "x"}, X: [][]float64{{3, 1}}, IDs: []string{"r1"}},
+		},
+		{name: "empty", data: "", opts: readOptions{Sep: ','}, wantErr: true},
+		{name: "header only", data: "a,b\n", opts: readOptions{Sep: ',', Header: true}, wantErr: true},
+		{name: "not a number", data: "a,b\n1,x\n", opts: readOptions{Sep: ',', Header: true}, wantErr: true},
+		{name: "not a finite number", data: "a,b\n1,2\n3,-Inf\n", opts: readOptions{Sep: ',', Header: true}, wantErr: true},
+		{name: "NaN", data: "1,nan\n", opts: readOptions{Sep: ','}, wantErr: true},
+		{name: "missing value", data: "a,b\n1,\n", opts: readOptions{Sep: ',', Header: true}, wantErr: true},
+		{name: "unknown column", data: "a,b\n1,2\n", opts: readOptions{Sep: ',', Header: true, Columns: []string{"c"}}, wantErr: true},
+		{name: "unknown id", data: "a,b\n1,2\n", opts: readOptions{Sep: ',', Header: true, ID: "id"}, wantErr: true},
+		{name: "invalid label", data: "a,l\n1,2\n", opts: readOptions{Sep: ',', Header: true, Label: "l"}, wantErr: true},
+		{name: "short record", data: "a,b\n1,2\n3\n", opts: readOptions{Sep: ',', Header: true}, wantErr: true},
+		{name: "no feature", data: "id\nr1\n", opts: readOptions{Sep: ',', Header: true, ID: "id"}, wantErr: true},
+	}
+	for _, tt := range tests {
+
+		got, err := readData(strings.NewReader(tt.data), tt.opts)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: readData() error = %v, wantErr %v", tt.name, err, tt.wantErr)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: readData() = %+v, want %+v", tt.name, got, tt.want)
+		}
+
+	}
+}
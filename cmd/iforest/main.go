@@ -0,0 +1,66 @@
+// Command iforest trains isolation forests and scores CSV or TSV files with
+// them.
+//
+// Usage:
+//
+//	iforest train   -in data.csv -model model.json [-trees 100] [-subsample 256] [-ratio 0.01] [-seed 0]
+//	iforest test    -in data.csv -model model.json [-out scores.csv]
+//	iforest predict -in data.csv -model model.json [-out scores.csv] [-format csv|jsonl]
+//	iforest inspect -model model.json
+//	iforest eval    -in labelled.csv -model model.json -label class
+//
+// Train builds the trees and chooses the anomaly bound on the same data, test
+// chooses the bound again on another dataset and updates the model, predict
+// writes the label (1 for anomalies) and the score of each row and eval
+// compares them with a label column.
+//
+// Data files have a header by default (-header=false otherwise). Features are
+// all columns but the ID and label ones, or the ones listed with -columns, by
+// name or by 1-based index without header. The -id column is copied to the
+// output. Files ending with .tsv are tab separated, "-" reads the standard
+// input. Models record the names of their features, the other commands read
+// the same columns by default, whatever their order in the file.
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+)
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
+
+// run runs the command with given arguments and returns its exit code.
+func run(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	if len(args) == 0 {
+		usage(stderr)
+		return 2
+	}
+	commands := map[string]func([]string, io.Reader, io.Writer, io.Writer) error{
+		"train":   trainCmd,
+		"test":    testCmd,
+		"predict": predictCmd,
+		"inspect": inspectCmd,
+		"eval":    evalCmd,
+	}
+	cmd, ok := commands[args[0]]
+	if !ok {
+		fmt.Fprintf(stderr, "iforest: unknown command %q\n", args[0])
+		usage(stderr)
+		return 2
+	}
+	if err := cmd(args[1:], stdin, stdout, stderr); err != nil {
+		if err != errUsage {
+			fmt.Fprintf(stderr, "iforest %s: %v\n", args[0], err)
+		}
+		return 1
+	}
+	return 0
+}
+
+func usage(w io.Writer) {
+	fmt.Fprintln(w, "usage: iforest <train|test|predict|inspect|eval> [flags]")
+	fmt.Fprintln(w, "run iforest <command> -h for the flags of a command")
+}
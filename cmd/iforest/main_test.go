@@ -0,0 +1,126 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeData writes a labelled CSV file with an anomaly every 50 rows.
+func writeData(t *testing.T, path string, n int) {
+	rnd := rand.New(rand.NewSource(int64(n)))
+	var b strings.Builder
+	b.WriteString("id,x,y,class\n")
+	for i := 0; i < n; i++ {
+		x, y, class := rnd.NormFloat64(), rnd.NormFloat64(), 0
+		if i%50 == 0 {
+			x, class = x+10, 1
+		}
+		fmt.Fprintf(&b, "row%d,%g,%g,%d\n", i, x, y, class)
+	}
+	if err := os.WriteFile(path, []byte(b.String()), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func runCmd(t *testing.T, stdin string, args ...string) (string, string, int) {
+	var stdout, stderr bytes.Buffer
+	code := run(args, strings.NewReader(stdin), &stdout, &stderr)
+	return stdout.String(), stderr.String(), code
+}
+
+func TestRun(t *testing.T) {
+	dir := t.TempDir()
+	data := filepath.Join(dir, "data.csv")
+	other := filepath.Join(dir, "other.csv")
+	model := filepath.Join(dir, "model.json")
+	writeData(t, data, 500)
+	writeData(t, other, 300)
+
+	if _, stderr, code := runCmd(t, "", "train", "-in", data, "-model", model, "-id", "id", "-columns", "x,y", "-trees", "50", "-ratio", "0.02", "-seed", "1"); code != 0 {
+		t.Fatalf("iforest train exit code = %v: %s", code, stderr)
+	}
+
+	stdout, stderr, code := runCmd(t, "", "predict", "-in", data, "-model", model, "-id", "id", "-columns", "x,y")
+	if code != 0 {
+		t.Fatalf("iforest predict exit code = %v: %s", code, stderr)
+	}
+	lines := strings.Split(strings.TrimSpace(stdout), "\n")
+	if len(lines) != 501 || lines[0] != "id,label,score" || !strings.HasPrefix(lines[1], "row0,1,") {
+		t.Errorf("iforest predict output = %v lines, starting with %q and %q", len(lines), lines[0], lines[1])
+	}
+
+	// the columns of the model are read by name
+	swapped := filepath.Join(dir, "swapped.csv")
+	b, _ := os.ReadFile(data)
+	var s strings.Builder
+	for _, line := range strings.Split(strings.TrimSpace(string(b)), "\n") {
+		fields := strings.Split(line, ",")
+		fmt.Fprintf(&s, "%s,%s,%s\n", fields[2], fields[0], fields[1])
+	}
+	if err := os.WriteFile(swapped, []byte(s.String()), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if got, stderr, _ := runCmd(t, "", "predict", "-in", swapped, "-model", model, "-id", "id"); got != stdout {
+		t.Errorf("iforest predict on swapped columns = %q, %s", strings.SplitN(got, "\n", 3)[:2], stderr)
+	}
+
+	stdout, _, code = runCmd(t, "", "predict", "-in", data, "-model", model, "-id", "id", "-columns", "x,y", "-format", "jsonl")
+	var row struct {
+		ID    string
+		Label int
+		Score float64
+	}
+	first := strings.SplitN(stdout, "\n", 2)[0]
+	if err := json.Unmarshal([]byte(first), &row); code != 0 || err != nil || row.ID != "row0" || row.Label != 1 {
+		t.Errorf("iforest predict -format jsonl first line = %q", first)
+	}
+
+	// the standard input, without header and with feature columns by index
+	stdout, stderr, code = runCmd(t, "1,2\n15,3\n", "predict", "-model", model, "-header=false")
+	if code != 0 || !strings.HasPrefix(stdout, "label,score\n0,") || !strings.Contains(stdout, "\n1,") {
+		t.Errorf("iforest predict from stdin = %q, %s", stdout, stderr)
+	}
+
+	out := filepath.Join(dir, "scores.csv")
+	if _, stderr, code := runCmd(t, "", "test", "-in", other, "-model", model, "-columns", "x,y", "-out", out); code != 0 {
+		t.Fatalf("iforest test exit code = %v: %s", code, stderr)
+	}
+	if b, _ := os.ReadFile(out); strings.Count(string(b), "\n") != 301 {
+		t.Errorf("iforest test wrote %v lines, want 301", strings.Count(string(b), "\n"))
+	}
+
+	stdout, stderr, code = runCmd(t, "", "eval", "-in", data, "-model", model, "-id", "id", "-label", "class")
+	if code != 0 || !strings.Contains(stdout, "roc auc") || !strings.Contains(stdout, "anomalies          10") {
+		t.Errorf("iforest eval = %q, %s", stdout, stderr)
+	}
+
+	stdout, _, code = runCmd(t, "", "inspect", "-model", model)
+	if code != 0 || !strings.Contains(stdout, "trees             50") || !strings.Contains(stdout, "feature names     x,y") {
+		t.Errorf("iforest inspect = %q", stdout)
+	}
+
+	errors := [][]string{
+		{},
+		{"unknown"},
+		{"train", "-in", data},
+		{"train", "-in", data, "-model", model, "-trees", "0"},
+		{"predict", "-in", data, "-model", filepath.Join(dir, "missing.json")},
+		{"predict", "-in", data, "-model", model, "-columns", "x,y,class"},
+		{"predict", "-in", data, "-model", model, "-columns", "x,y", "-format", "xml"},
+		{"eval", "-in", data, "-model", model, "-columns", "x,y"},
+		{"inspect", "-model", model, "extra"},
+	}
+	for _, args := range errors {
+
+		if _, _, code := runCmd(t, "", args...); code == 0 {
+			t.Errorf("iforest %v exit code = 0, want an error", args)
+		}
+
+	}
+}
@@ -0,0 +1,140 @@
+package iforest
+
+import (
+	"errors"
+	"math"
+	"sort"
+
+	"github.com/e-XpertSolutions/go-iforest/v2/iforest/eval"
+)
+
+// Aggregation is the way path lengths of a vector in the trees are combined
+// into the path length normalised by the anomaly score.
+type Aggregation string
+
+// Aggregations.
+const (
+	// MeanAggregation takes the arithmetic mean of the path lengths, as
+	// described in algorithm specification. It is the default.
+	MeanAggregation Aggregation = ""
+	// MedianAggregation takes the median of the path lengths.
+	MedianAggregation Aggregation = "median"
+	// TrimmedMeanAggregation takes the mean of the path lengths after
+	// discarding the TrimRatio lowest and the TrimRatio highest ones.
+	TrimmedMeanAggregation Aggregation = "trimmed"
+	// WeightedAggregation takes the mean of the path lengths weighted by
+	// TreeWeights.
+	WeightedAggregation Aggregation = "weighted"
+)
+
+// LearnTreeWeights weights each tree by its agreement with the whole forest on
+// given dataset: the Spearman's rank correlation between the path lengths in
+// the tree and the aggregated path lengths, trees with a negative correlation
+// getting no weight. The forest then uses WeightedAggregation.
+func (f *Forest) LearnTreeWeights(X [][]float64) error {
+	if !f.Trained {
+		return errors.New("cannot learn weights - model has not been trained yet")
+	}
+	if err := f.checkAggregation(); err != nil {
+		return err
+	}
+
+	consensus := make([]float64, len(X))
+	paths := make([][]float64, len(f.Trees))
+	for j := range paths {
+		paths[j] = make([]float64, len(X))
+	}
+	for i := range X {
+		consensus[i] = f.averagePath(RowSlices(X), i)
+		for j := range f.Trees {
+			paths[j][i] = f.Trees[j].pathLength(RowSlices(X), i)
+		}
+	}
+
+	weights := make([]float64, len(f.Trees))
+	var total float64
+	for j := range f.Trees {
+		rho, err := eval.Spearman(paths[j], consensus)
+		if err == nil && rho > 0 {
+			weights[j] = rho
+			total += rho
+		}
+	}
+	if total == 0 {
+		return errors.New("cannot learn weights - no tree agrees with the forest")
+	}
+
+	f.TreeWeights = weights
+	f.Aggregation = WeightedAggregation
+	return nil
+}
+
+// checkAggregation verifies that the parameters of the aggregation are valid.
+func (f *Forest) checkAggregation() error {
+	return checkAggregation(f.Aggregation, f.TrimRatio, f.TreeWeights, len(f.Trees))
+}
+
+// checkAggregation verifies the parameters of an aggregation of the paths in
+// nbTrees trees.
+func checkAggregation(agg Aggregation, trimRatio float64, weights []float64, nbTrees int) error {
+	switch agg {
+	case MeanAggregation, MedianAggregation:
+	case TrimmedMeanAggregation:
+		if trimRatio < 0 || trimRatio >= 0.5 {
+			return errors.New("trim ratio must be between 0 and 0.5")
+		}
+	case WeightedAggregation:
+		if len(weights) != nbTrees {
+			return errors.New("there must be one weight per tree")
+		}
+		var total float64
+		for _, w := range weights {
+			if w < 0 {
+				return errors.New("tree weights cannot be negative")
+			}
+			total += w
+		}
+		if total == 0 {
+			return errors.New("tree weights cannot all be 0")
+		}
+	default:
+		return errors.New("unknown aggregation " + string(agg))
+	}
+	return nil
+}
+
+// aggregate combines path lengths of a vector in each tree. The paths may be
+// reordered.
+func (f *Forest) aggregate(paths []float64) float64 {
+	return aggregate(f.Aggregation, f.TrimRatio, f.TreeWeights, paths)
+}
+
+// aggregate combines path lengths of a vector in each tree with the given
+// aggregation. The paths may be reordered.
+func aggregate(agg Aggregation, trimRatio float64, weights, paths []float64) float64 {
+	switch agg {
+	case MedianAggregation:
+		sort.Float64s(paths)
+		return quantile(paths, 0.5)
+	case TrimmedMeanAggregation:
+		sort.Float64s(paths)
+		k := int(math.Floor(trimRatio * float64(len(paths))))
+		var sum float64
+		for _, p := range paths[k : len(paths)-k] {
+			sum += p
+		}
+		return sum / float64(len(paths)-2*k)
+	case WeightedAggregation:
+		var sum, total float64
+		for j, p := range paths {
+			sum += weights[j] * p
+			total += weights[j]
+		}
+		return sum / total
+	}
+	var sum float64
+	for _, p := range paths {
+		sum += p
+	}
+	return sum / float64(len(paths))
+}
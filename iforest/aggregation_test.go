@@ -0,0 +1,156 @@
+package iforest
+
+import (
+	"bytes"
+	"encoding/json"
+	"math"
+	"sort"
+	"testing"
+)
+
+func TestForest_aggregate(t *testing.T) {
+	tests := []struct {
+		name  string
+		f     *Forest
+		paths []float64
+		want  float64
+	}{
+		{name: "mean", f: &Forest{}, paths: []float64{1, 2, 3, 10}, want: 4},
+		{name: "median odd", f: &Forest{Aggregation: MedianAggregation}, paths: []float64{3, 10, 1}, want: 3},
+		{name: "median even", f: &Forest{Aggregation: MedianAggregation}, paths: []float64{1, 2, 3, 10}, want: 2.5},
+		{name: "trimmed", f: &Forest{Aggregation: TrimmedMeanAggregation, TrimRatio: 0.25}, paths: []float64{10, 1, 2, 3}, want: 2.5},
+		{name: "trimmed none", f: &Forest{Aggregation: TrimmedMeanAggregation}, paths: []float64{1, 2, 3, 10}, want: 4},
+		{name: "weighted", f: &Forest{Aggregation: WeightedAggregation, TreeWeights: []float64{1, 0, 0, 1}}, paths: []float64{1, 2, 3, 10}, want: 5.5},
+	}
+	for _, tt := range tests {
+
+		if got := tt.f.aggregate(tt.paths); math.Abs(got-tt.want) > 1e-12 {
+			t.Errorf("%s: Forest.aggregate() = %v, want %v", tt.name, got, tt.want)
+		}
+
+	}
+}
+
+func TestForest_checkAggregation(t *testing.T) {
+	trees := make([]Tree, 2)
+	tests := []struct {
+		name    string
+		f       *Forest
+		wantErr bool
+	}{
+		{name: "mean", f: &Forest{Trees: trees}},
+		{name: "median", f: &Forest{Trees: trees, Aggregation: MedianAggregation}},
+		{name: "trimmed", f: &Forest{Trees: trees, Aggregation: TrimmedMeanAggregation, TrimRatio: 0.1}},
+		{name: "trimmed negative", f: &Forest{Trees: trees, Aggregation: TrimmedMeanAggregation, TrimRatio: -0.1}, wantErr: true},
+		{name: "trimmed half", f: &Forest{Trees: trees, Aggregation: TrimmedMeanAggregation, TrimRatio: 0.5}, wantErr: true},
+		{name: "weighted", f: &Forest{Trees: trees, Aggregation: WeightedAggregation, TreeWeights: []float64{0, 1}}},
+		{name: "weighted missing", f: &Forest{Trees: trees, Aggregation: WeightedAggregation, TreeWeights: []float64{1}}, wantErr: true},
+		{name: "weighted negative", f: &Forest{Trees: trees, Aggregation: WeightedAggregation, TreeWeights: []float64{2, -1}}, wantErr: true},
+		{name: "weighted zero", f: &Forest{Trees: trees, Aggregation: WeightedAggregation, TreeWeights: []float64{0, 0}}, wantErr: true},
+		{name: "unknown", f: &Forest{Trees: trees, Aggregation: "mode"}, wantErr: true},
+	}
+	for _, tt := range tests {
+
+		if err := tt.f.checkAggregation(); (err != nil) != tt.wantErr {
+			t.Errorf("%s: Forest.checkAggregation() error = %v, wantErr %v", tt.name, err, tt.wantErr)
+		}
+
+	}
+}
+
+func TestForest_Aggregation(t *testing.T) {
+	_, X := randomData32(200, 3)
+
+	f := NewForest(50, 64, 0.05)
+	f.Seed = 7
+	f.Train(X)
+
+	for _, agg := range []Aggregation{MedianAggregation, TrimmedMeanAggregation} {
+		f.Aggregation = agg
+		f.TrimRatio = 0.1
+		if err := f.Test(X); err != nil {
+			t.Fatalf("Forest.Test() with %q error = %v", agg, err)
+		}
+		labels, scores, err := f.Predict(X)
+		if err != nil {
+			t.Fatalf("Forest.Predict() with %q error = %v", agg, err)
+		}
+		_, parallel, _ := f.PredictParallel(X, 4)
+		averages, _ := f.AveragePathLength(X)
+		for i := range X {
+			paths, _ := f.PathLengths(X[i])
+			sort.Float64s(paths)
+			var want float64
+			if agg == MedianAggregation {
+				want = (paths[24] + paths[25]) / 2
+			} else {
+				for _, p := range paths[5:45] {
+					want += p
+				}
+				want /= 40
+			}
+			if math.Abs(averages[i]-want) > 1e-12 {
+				t.Errorf("Forest.AveragePathLength() with %q = %v, want %v", agg, averages[i], want)
+			}
+			if scores[i] != f.AnomalyScores[i] || parallel[i] != scores[i] {
+				t.Errorf("Forest.Predict() with %q = %v, Test gave %v", agg, scores[i], f.AnomalyScores[i])
+			}
+			if labels[i] != f.Labels[i] {
+				t.Errorf("Forest.Predict() with %q labels %v, Test gave %v", agg, labels[i], f.Labels[i])
+			}
+		}
+	}
+
+	f.TrimRatio = 0.5
+	if err := f.Test(X); err == nil {
+		t.Errorf("Forest.Test() with an invalid trim ratio should fail")
+	}
+	if _, _, err := f.Predict(X); err == nil {
+		t.Errorf("Forest.Predict() with an invalid trim ratio should fail")
+	}
+}
+
+func TestForest_LearnTreeWeights(t *testing.T) {
+	_, X := randomData32(200, 3)
+
+	f := NewForest(50, 64, 0.05)
+	if err := f.LearnTreeWeights(X); err == nil {
+		t.Errorf("Forest.LearnTreeWeights() should fail before training")
+	}
+	f.Seed = 3
+	f.Train(X)
+	if err := f.LearnTreeWeights(X); err != nil {
+		t.Fatalf("Forest.LearnTreeWeights() error = %v", err)
+	}
+	if f.Aggregation != WeightedAggregation || len(f.TreeWeights) != len(f.Trees) {
+		t.Fatalf("Forest.LearnTreeWeights() = %q with %v weights", f.Aggregation, len(f.TreeWeights))
+	}
+	for _, w := range f.TreeWeights {
+		if w < 0 || w > 1 {
+			t.Errorf("Forest.LearnTreeWeights() weight = %v, want in [0, 1]", w)
+		}
+	}
+	if err := f.Test(X); err != nil {
+		t.Fatalf("Forest.Test() error = %v", err)
+	}
+	_, scores, _ := f.Predict(X)
+
+	// weights are persisted with the model
+	b, err := json.Marshal(f)
+	if err != nil {
+		t.Fatalf("json.Marshal() error = %v", err)
+	}
+	g := &Forest{}
+	if err := json.NewDecoder(bytes.NewReader(b)).Decode(g); err != nil {
+		t.Fatalf("json.Decode() error = %v", err)
+	}
+	_, loaded, err := g.Predict(X)
+	if err != nil {
+		t.Fatalf("Forest.Predict() after loading error = %v", err)
+	}
+	for i := range scores {
+		if loaded[i] != scores[i] {
+			t.Errorf("Forest.Predict() after loading = %v, want %v", loaded[i], scores[i])
+		}
+	}
+}
@@ -0,0 +1,194 @@
+package iforest
+
+import (
+	"errors"
+	"math"
+	"sort"
+)
+
+// CalibrationMethod identifies the way anomaly scores are mapped to outlier
+// probabilities.
+type CalibrationMethod string
+
+// Calibration methods.
+const (
+	// GaussianCalibration assumes normally distributed scores and maps them
+	// with the gaussian scaling of Kriegel et al. "Interpreting and Unifying
+	// Outlier Scores". Scores above the mean get a probability of 0.
+	GaussianCalibration CalibrationMethod = "gaussian"
+	// IsotonicCalibration maps scores with a non-increasing function fitted on
+	// labelled data.
+	IsotonicCalibration CalibrationMethod = "isotonic"
+)
+
+// Calibration maps anomaly scores to outlier probabilities in [0, 1]. Lower
+// scores get higher probabilities.
+type Calibration struct {
+	Method CalibrationMethod
+
+	// Mean and Std are the parameters of the gaussian scaling.
+	Mean float64
+	Std  float64
+
+	// Scores and Probas are the knots of the isotonic function, which is
+	// linearly interpolated between them. Scores are sorted in increasing
+	// order.
+	Scores []float64
+	Probas []float64
+}
+
+// clone returns a copy of the calibration, nil for a nil calibration.
+func (c *Calibration) clone() *Calibration {
+	if c == nil {
+		return nil
+	}
+	copied := *c
+	copied.Scores = append([]float64(nil), c.Scores...)
+	copied.Probas = append([]float64(nil), c.Probas...)
+	return &copied
+}
+
+// FitGaussian fits the gaussian scaling on the scores of a dataset.
+func FitGaussian(scores []float64) (*Calibration, error) {
+	if len(scores) < 2 {
+		return nil, errors.New("cannot calibrate - not enough scores")
+	}
+	var mean, variance float64
+	for _, s := range scores {
+		mean += s
+	}
+	mean /= float64(len(scores))
+	for _, s := range scores {
+		variance += (s - mean) * (s - mean)
+	}
+	std := math.Sqrt(variance / float64(len(scores)-1))
+	if std == 0 {
+		return nil, errors.New("cannot calibrate - all scores are equal")
+	}
+	return &Calibration{Method: GaussianCalibration, Mean: mean, Std: std}, nil
+}
+
+// FitIsotonic fits a non-increasing function of the scores on the given labels,
+// 1 for anomalies and 0 for normal vectors, with the pool adjacent violators
+// algorithm.
+func FitIsotonic(scores []float64, labels []int) (*Calibration, error) {
+	if len(scores) != len(labels) {
+		return nil, errors.New("cannot calibrate - scores and labels lengths differ")
+	}
+	if len(scores) == 0 {
+		return nil, errors.New("cannot calibrate - no scores")
+	}
+
+	ids := make([]int, len(scores))
+	for i := range ids {
+		ids[i] = i
+		if labels[i] != 0 && labels[i] != 1 {
+			return nil, errors.New("cannot calibrate - labels must be 0 or 1")
+		}
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		return scores[ids[i]] > scores[ids[j]]
+	})
+
+	// blocks of equal scores first, then pooled while they violate the order
+	type block struct {
+		score, proba, weight float64
+	}
+	var blocks []block
+	for _, id := range ids {
+		s, y := scores[id], float64(labels[id])
+		if n := len(blocks); n > 0 && blocks[n-1].score == s {
+			b := &blocks[n-1]
+			b.proba = (b.proba*b.weight + y) / (b.weight + 1)
+			b.weight++
+		} else {
+			blocks = append(blocks, block{score: s, proba: y, weight: 1})
+		}
+		for n := len(blocks); n > 1 && blocks[n-2].proba >= blocks[n-1].proba; n = len(blocks) {
+			a, b := blocks[n-2], blocks[n-1]
+			w := a.weight + b.weight
+			blocks[n-2] = block{
+				score:  (a.score*a.weight + b.score*b.weight) / w,
+				proba:  (a.proba*a.weight + b.proba*b.weight) / w,
+				weight: w,
+			}
+			blocks = blocks[:n-1]
+		}
+	}
+
+	c := &Calibration{Method: IsotonicCalibration}
+	for i := len(blocks) - 1; i >= 0; i-- {
+		c.Scores = append(c.Scores, blocks[i].score)
+		c.Probas = append(c.Probas, blocks[i].proba)
+	}
+	return c, nil
+}
+
+// Proba returns the outlier probability of given anomaly score.
+func (c *Calibration) Proba(score float64) float64 {
+	switch c.Method {
+	case GaussianCalibration:
+		return math.Max(0, math.Erf((c.Mean-score)/(c.Std*math.Sqrt2)))
+	case IsotonicCalibration:
+		n := len(c.Scores)
+		if score <= c.Scores[0] {
+			return c.Probas[0]
+		}
+		if score >= c.Scores[n-1] {
+			return c.Probas[n-1]
+		}
+		i := sort.SearchFloat64s(c.Scores, score)
+		t := (score - c.Scores[i-1]) / (c.Scores[i] - c.Scores[i-1])
+		return c.Probas[i-1] + t*(c.Probas[i]-c.Probas[i-1])
+	}
+	return math.NaN()
+}
+
+// CalibrateIsotonic replaces the calibration fitted during the testing stage
+// by an isotonic one, fitted on the labelled dataset. Labels are 1 for
+// anomalies and 0 for normal vectors.
+func (f *Forest) CalibrateIsotonic(X [][]float64, labels []int) error {
+	if !f.Trained {
+		return errors.New("cannot calibrate - model has not been trained yet")
+	}
+	if err := f.checkAggregation(); err != nil {
+		return err
+	}
+	scores := make([]float64, len(X))
+	for i := range X {
+		scores[i] = f.score(RowSlices(X), i)
+	}
+	c, err := FitIsotonic(scores, labels)
+	if err != nil {
+		return err
+	}
+	f.Calibration = c
+	return nil
+}
+
+// PredictProba computes outlier probabilities for given dataset with the
+// calibration of the model.
+func (f *Forest) PredictProba(X [][]float64) ([]float64, error) {
+	return f.PredictProbaMatrix(RowSlices(X))
+}
+
+// PredictProbaMatrix does the same as PredictProba but reads the data from a
+// Matrix.
+func (f *Forest) PredictProbaMatrix(X Matrix) ([]float64, error) {
+	if !f.Trained {
+		return nil, errors.New("cannot predict - model has not been trained yet")
+	}
+	if err := f.checkAggregation(); err != nil {
+		return nil, err
+	}
+	if f.Calibration == nil {
+		return nil, errors.New("cannot predict probabilities - model has not been calibrated yet")
+	}
+
+	xRows, _ := X.Dims()
+	probas := make([]float64, xRows)
+	for i := 0; i < xRows; i++ {
+		probas[i] = f.Calibration.Proba(f.score(X, i))
+	}
+	return probas, nil
+}
@@ -0,0 +1,152 @@
+package iforest
+
+import (
+	"math"
+	"math/rand"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestFitGaussian(t *testing.T) {
+	tests := []struct {
+		scores  []float64
+		wantErr bool
+	}{
+		{scores: bimodalScores(950, 50), wantErr: false},
+		{scores: []float64{1}, wantErr: true},
+		{scores: []float64{1, 1, 1}, wantErr: true},
+	}
+	for _, tt := range tests {
+
+		c, err := FitGaussian(tt.scores)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("FitGaussian() error = %v, wantErr %v", err, tt.wantErr)
+		}
+		if err != nil {
+			continue
+		}
+		if p := c.Proba(c.Mean); p != 0 {
+			t.Errorf("Calibration.Proba(mean) = %v, want 0", p)
+		}
+		if p := c.Proba(c.Mean - 10*c.Std); p < 0.99 || p > 1 {
+			t.Errorf("Calibration.Proba(mean - 10 std) = %v, want close to 1", p)
+		}
+
+	}
+}
+
+func TestFitIsotonic(t *testing.T) {
+	tests := []struct {
+		scores     []float64
+		labels     []int
+		wantScores []float64
+		wantProbas []float64
+		wantErr    bool
+	}{
+		{scores: []float64{-0.2, -0.1, 0, 0.1}, labels: []int{1, 1, 0, 0}, wantScores: []float64{-0.15, 0.05}, wantProbas: []float64{1, 0}},
+		{scores: []float64{-0.2, -0.1, 0, 0.1}, labels: []int{1, 0, 1, 0}, wantScores: []float64{-0.2, -0.05, 0.1}, wantProbas: []float64{1, 0.5, 0}},
+		{scores: []float64{0, 0, 1}, labels: []int{1, 0, 0}, wantScores: []float64{0, 1}, wantProbas: []float64{0.5, 0}},
+		{scores: []float64{0, 1}, labels: []int{1}, wantErr: true},
+		{scores: []float64{0, 1}, labels: []int{1, 2}, wantErr: true},
+		{scores: nil, labels: nil, wantErr: true},
+	}
+	for _, tt := range tests {
+
+		c, err := FitIsotonic(tt.scores, tt.labels)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("FitIsotonic() error = %v, wantErr %v", err, tt.wantErr)
+		}
+		if err != nil {
+			continue
+		}
+		if len(c.Scores) != len(tt.wantScores) || !reflect.DeepEqual(c.Probas, tt.wantProbas) {
+			t.Errorf("FitIsotonic() = %v, %v, want %v, %v", c.Scores, c.Probas, tt.wantScores, tt.wantProbas)
+			continue
+		}
+		for i := range c.Scores {
+			if math.Abs(c.Scores[i]-tt.wantScores[i]) > 1e-12 {
+				t.Errorf("FitIsotonic() = %v, %v, want %v, %v", c.Scores, c.Probas, tt.wantScores, tt.wantProbas)
+			}
+		}
+
+	}
+}
+
+func TestCalibration_Proba(t *testing.T) {
+	c := &Calibration{Method: IsotonicCalibration, Scores: []float64{-0.2, 0, 0.2}, Probas: []float64{1, 0.5, 0}}
+
+	tests := []struct {
+		score float64
+		want  float64
+	}{
+		{score: -1, want: 1},
+		{score: -0.2, want: 1},
+		{score: -0.1, want: 0.75},
+		{score: 0.1, want: 0.25},
+		{score: 1, want: 0},
+	}
+	for _, tt := range tests {
+
+		if got := c.Proba(tt.score); math.Abs(got-tt.want) > 1e-12 {
+			t.Errorf("Calibration.Proba(%v) = %v, want %v", tt.score, got, tt.want)
+		}
+
+	}
+}
+
+func TestForest_PredictProba(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	X := make([][]float64, 300)
+	labels := make([]int, len(X))
+	for i := range X {
+		X[i] = []float64{r.NormFloat64(), r.NormFloat64()}
+		if i%30 == 0 {
+			X[i] = []float64{6 + r.NormFloat64(), -6 + r.NormFloat64()}
+			labels[i] = 1
+		}
+	}
+
+	f := NewForest(50, 64, 0.1)
+	if _, err := f.PredictProba(X); err == nil {
+		t.Errorf("Forest.PredictProba() should fail before training")
+	}
+	f.Train(X)
+	f.Test(X)
+	if f.Calibration == nil || f.Calibration.Method != GaussianCalibration {
+		t.Fatalf("Forest.Test() calibration = %v, want gaussian", f.Calibration)
+	}
+
+	probas, err := f.PredictProba(X)
+	if err != nil {
+		t.Fatalf("Forest.PredictProba() error = %v", err)
+	}
+	for i, p := range probas {
+		if p < 0 || p > 1 {
+			t.Errorf("Forest.PredictProba() = %v, want a probability", p)
+		}
+		if labels[i] == 1 && p < 0.9 {
+			t.Errorf("Forest.PredictProba() of anomaly %d = %v, want close to 1", i, p)
+		}
+	}
+
+	if err := f.CalibrateIsotonic(X, labels); err != nil {
+		t.Fatalf("Forest.CalibrateIsotonic() error = %v", err)
+	}
+	f.Test(X)
+	if f.Calibration.Method != IsotonicCalibration {
+		t.Errorf("Forest.Test() replaced the isotonic calibration")
+	}
+
+	path := filepath.Join(t.TempDir(), "model")
+	f.Save(path)
+	loaded := &Forest{}
+	if err := loaded.Load(path); err != nil {
+		t.Fatalf("Forest.Load() error = %v", err)
+	}
+	want, _ := f.PredictProba(X)
+	got, err := loaded.PredictProba(X)
+	if err != nil || !reflect.DeepEqual(got, want) {
+		t.Errorf("loaded Forest.PredictProba() = %v, %v, want %v", got, err, want)
+	}
+}
@@ -0,0 +1,126 @@
+package dataio
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// csvIterator reads the records of a CSV file.
+type csvIterator struct {
+	reader  *csv.Reader
+	columns []string
+	indices []int
+	// keys are the indices of the key columns.
+	keys    []int
+	missing map[string]bool
+	// first is the first record when it holds data.
+	first []string
+	line  int
+	row   []float64
+	key   []string
+	err   error
+}
+
+// NewCSVIterator returns an iterator over the records of a CSV file. The
+// header, if any, is read immediately.
+func NewCSVIterator(r io.Reader, opts Options) (Iterator, error) {
+	reader := csv.NewReader(r)
+	if opts.Comma != 0 {
+		reader.Comma = opts.Comma
+	}
+	record, err := reader.Read()
+	if err == io.EOF {
+		return nil, fmt.Errorf("no data")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	it := &csvIterator{reader: reader, missing: opts.missingSet(), line: 1}
+	names := make([]string, len(record))
+	for j := range record {
+		if opts.NoHeader {
+			names[j] = strconv.Itoa(j + 1)
+		} else {
+			names[j] = strings.TrimSpace(record[j])
+		}
+	}
+	if opts.NoHeader {
+		it.first = record
+		it.line = 0
+	}
+	if it.indices, it.keys, err = selectColumns(names, opts.Columns, opts.Keys); err != nil {
+		return nil, err
+	}
+	it.columns = make([]string, len(it.indices))
+	for k, j := range it.indices {
+		it.columns[k] = names[j]
+	}
+	return it, nil
+}
+
+// ReadCSV reads a whole CSV file.
+func ReadCSV(r io.Reader, opts Options) (*Dataset, error) {
+	it, err := NewCSVIterator(r, opts)
+	if err != nil {
+		return nil, err
+	}
+	return Collect(it)
+}
+
+func (it *csvIterator) Columns() []string {
+	return it.columns
+}
+
+func (it *csvIterator) Next() bool {
+	if it.err != nil {
+		return false
+	}
+	record := it.first
+	if record != nil {
+		it.first = nil
+	} else if record, it.err = it.reader.Read(); it.err != nil {
+		if it.err == io.EOF {
+			it.err = nil
+		}
+		return false
+	}
+	it.line++
+
+	row := make([]float64, len(it.indices))
+	for k, j := range it.indices {
+		v, err := parseString(record[j], it.missing)
+		if err != nil {
+			it.err = fmt.Errorf("line %d, column %s: %v", it.line, it.columns[k], err)
+			return false
+		}
+		row[k] = v
+	}
+	it.row = row
+	if len(it.keys) > 0 {
+		it.key = make([]string, len(it.keys))
+		for k, j := range it.keys {
+			it.key[k] = record[j]
+		}
+	}
+	return true
+}
+
+func (it *csvIterator) Row() []float64 {
+	return it.row
+}
+
+func (it *csvIterator) Keys() []string {
+	return it.key
+}
+
+func (it *csvIterator) Err() error {
+	return it.err
+}
+
+func (it *csvIterator) Close() error {
+	return nil
+}
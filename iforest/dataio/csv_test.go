@@ -0,0 +1,125 @@
+package dataio
+
+import (
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// equalRows compares rows, NaN values being equal.
+func equalRows(a, b [][]float64) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if len(a[i]) != len(b[i]) {
+			return false
+		}
+		for j := range a[i] {
+			if a[i][j] != b[i][j] && !(math.IsNaN(a[i][j]) && math.IsNaN(b[i][j])) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func TestReadCSV(t *testing.T) {
+	nan := math.NaN()
+	tests := []struct {
+		name        string
+		data        string
+		opts        Options
+		wantColumns []string
+		wantX       [][]float64
+		wantKeys    [][]string
+		wantErr     bool
+	}{
+		{
+			name:        "all columns",
+			data:        "a,b,c\n1,2,3\n4,5.5,-6e1\n",
+			wantColumns: []string{"a", "b", "c"},
+			wantX:       [][]float64{{1, 2, 3}, {4, 5.5, -60}},
+		},
+		{
+			name:        "selected columns",
+			data:        "id,x,y\nr1,1,2\nr2,3,4\n",
+			opts:        Options{Columns: []string{"y", "x"}},
+			wantColumns: []string{"y", "x"},
+			wantX:       [][]float64{{2, 1}, {4, 3}},
+		},
+		{
+			name:        "missing values and booleans",
+			data:        "a,b,c\n,NA,true\n?, 7 ,False\n",
+			wantColumns: []string{"a", "b", "c"},
+			wantX:       [][]float64{{nan, nan, 1}, {nan, 7, 0}},
+		},
+		{
+			name:        "custom missing markers",
+			data:        "a;b\n-999;1\n",
+			opts:        Options{Comma: ';', Missing: []string{"-999"}},
+			wantColumns: []string{"a", "b"},
+			wantX:       [][]float64{{nan, 1}},
+		},
+		{
+			name:        "no header",
+			data:        "1,2\n3,4\n",
+			opts:        Options{NoHeader: true, Columns: []string{"2"}},
+			wantColumns: []string{"2"},
+			wantX:       [][]float64{{2}, {4}},
+		},
+		{
+			name:        "keys",
+			data:        "id,x,class\nr1,1, 0\n,2,1\n",
+			opts:        Options{Keys: []string{"class", "id"}},
+			wantColumns: []string{"x"},
+			wantX:       [][]float64{{1}, {2}},
+			wantKeys:    [][]string{{" 0", "r1"}, {"1", ""}},
+		},
+		{name: "empty", data: "", wantErr: true},
+		{name: "unknown key", data: "a\n1\n", opts: Options{Keys: []string{"b"}}, wantErr: true},
+		{name: "unknown column", data: "a\n1\n", opts: Options{Columns: []string{"b"}}, wantErr: true},
+		{name: "not a number", data: "a\nx\n", wantErr: true},
+		{name: "no missing markers", data: "a\nNA\n", opts: Options{Missing: []string{}}, wantErr: true},
+		{name: "short record", data: "a,b\n1,2\n3\n", wantErr: true},
+	}
+	for _, tt := range tests {
+
+		d, err := ReadCSV(strings.NewReader(tt.data), tt.opts)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: ReadCSV() error = %v, wantErr %v", tt.name, err, tt.wantErr)
+			continue
+		}
+		if err != nil {
+			continue
+		}
+		if !reflect.DeepEqual(d.Columns, tt.wantColumns) || !equalRows(d.X, tt.wantX) || !reflect.DeepEqual(d.Keys, tt.wantKeys) {
+			t.Errorf("%s: ReadCSV() = %v %v %v, want %v %v %v", tt.name, d.Columns, d.X, d.Keys, tt.wantColumns, tt.wantX, tt.wantKeys)
+		}
+
+	}
+}
+
+func TestCSVIterator(t *testing.T) {
+	it, err := NewCSVIterator(strings.NewReader("a,b\n1,2\n3,x\n5,6\n"), Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !it.Next() || !reflect.DeepEqual(it.Row(), []float64{1, 2}) {
+		t.Fatalf("csvIterator.Next() = %v, want [1 2]", it.Row())
+	}
+	row := it.Row()
+	if it.Next() {
+		t.Errorf("csvIterator.Next() = true on an invalid record")
+	}
+	if err := it.Err(); err == nil || !strings.Contains(err.Error(), "line 3, column b") {
+		t.Errorf("csvIterator.Err() = %v, want an error at line 3, column b", err)
+	}
+	if it.Next() {
+		t.Errorf("csvIterator.Next() = true after an error")
+	}
+	if !reflect.DeepEqual(row, []float64{1, 2}) {
+		t.Errorf("csvIterator reused a row: %v", row)
+	}
+}
@@ -0,0 +1,240 @@
+// Package dataio loads datasets from CSV, JSON Lines and Parquet files into
+// the [][]float64 rows used by iforest.Forest.
+//
+// Columns are selected by name. Values are converted to float64: numbers are
+// kept, booleans become 0 or 1, strings are parsed and missing values (empty
+// fields, JSON nulls, Parquet nulls and the markers of Options.Missing) become
+// NaN. Forests do not handle NaN values, they should be imputed before
+// training, or the rows dropped.
+//
+// Load and the Read functions read a whole dataset in memory, Open and the
+// Iterator constructors stream it row by row:
+//
+//	it, err := dataio.Open("data.parquet", dataio.Options{Columns: []string{"x", "y"}})
+//	if err != nil {
+//		return err
+//	}
+//	defer it.Close()
+//	for it.Next() {
+//		row := it.Row()
+//		...
+//	}
+//	return it.Err()
+package dataio
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// DefaultMissing are the markers of missing values used when
+// Options.Missing is nil.
+var DefaultMissing = []string{"", "NA", "N/A", "NaN", "nan", "null", "NULL", "None", "?"}
+
+// Options selects and converts the columns of a dataset.
+type Options struct {
+	// Columns are the names of the columns read, in this order. All columns
+	// but the keys are read when empty, in the order of the file.
+	Columns []string
+	// Keys are the names of columns read as text, such as row identifiers,
+	// and returned by Iterator.Keys.
+	Keys []string
+	// Missing are the string values read as NaN, DefaultMissing when nil.
+	Missing []string
+	// Comma is the CSV field separator, ',' when 0 (tab for .tsv files
+	// opened with Open or Load).
+	Comma rune
+	// NoHeader tells that the first CSV record holds data. Columns are then
+	// named by their 1-based index.
+	NoHeader bool
+	// BatchSize is the number of Parquet rows read at once, 1024 when 0.
+	BatchSize int
+}
+
+// Dataset is a table of float64 values.
+type Dataset struct {
+	Columns []string
+	X       [][]float64
+	// Keys holds the values of the key columns of each row, nil without
+	// keys.
+	Keys [][]string
+}
+
+// Iterator reads a dataset row by row.
+type Iterator interface {
+	// Columns returns the names of the columns of the rows.
+	Columns() []string
+	// Next reads the next row. It returns false at the end of the data or on
+	// error.
+	Next() bool
+	// Row returns the row read by Next. Rows are never reused by the
+	// iterator.
+	Row() []float64
+	// Keys returns the values of the key columns of the row read by Next,
+	// nil without keys. Missing values are empty strings.
+	Keys() []string
+	// Err returns the error that stopped Next, if any.
+	Err() error
+	// Close releases the resources of the iterator.
+	Close() error
+}
+
+// Collect reads all remaining rows of the iterator.
+func Collect(it Iterator) (*Dataset, error) {
+	d := &Dataset{Columns: it.Columns()}
+	for it.Next() {
+		d.X = append(d.X, it.Row())
+		if keys := it.Keys(); keys != nil {
+			d.Keys = append(d.Keys, keys)
+		}
+	}
+	if err := it.Err(); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+// Open opens a file with the iterator matching its extension: .csv, .tsv,
+// .jsonl, .ndjson or .parquet.
+func Open(path string, opts Options) (Iterator, error) {
+	ext := strings.ToLower(filepath.Ext(path))
+	switch ext {
+	case ".csv", ".tsv", ".jsonl", ".ndjson", ".parquet":
+	default:
+		return nil, fmt.Errorf("unknown file format %q", ext)
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var it Iterator
+	switch ext {
+	case ".csv":
+		it, err = NewCSVIterator(file, opts)
+	case ".tsv":
+		if opts.Comma == 0 {
+			opts.Comma = '\t'
+		}
+		it, err = NewCSVIterator(file, opts)
+	case ".jsonl", ".ndjson":
+		it, err = NewJSONLinesIterator(file, opts)
+	case ".parquet":
+		var info os.FileInfo
+		if info, err = file.Stat(); err == nil {
+			it, err = NewParquetIterator(file, info.Size(), opts)
+		}
+	}
+	if err != nil {
+		file.Close()
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	return &fileIterator{Iterator: it, file: file}, nil
+}
+
+// Load reads a whole file, see Open.
+func Load(path string, opts Options) (*Dataset, error) {
+	it, err := Open(path, opts)
+	if err != nil {
+		return nil, err
+	}
+	d, err := Collect(it)
+	if cerr := it.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+// fileIterator closes the file read by an iterator.
+type fileIterator struct {
+	Iterator
+	file *os.File
+}
+
+func (it *fileIterator) Close() error {
+	err := it.Iterator.Close()
+	if ferr := it.file.Close(); err == nil {
+		err = ferr
+	}
+	return err
+}
+
+// missingSet returns the set of missing value markers of the options.
+func (o Options) missingSet() map[string]bool {
+	markers := o.Missing
+	if markers == nil {
+		markers = DefaultMissing
+	}
+	set := make(map[string]bool, len(markers))
+	for _, m := range markers {
+		set[m] = true
+	}
+	return set
+}
+
+// parseString converts a string value, NaN for missing values.
+func parseString(s string, missing map[string]bool) (float64, error) {
+	s = strings.TrimSpace(s)
+	if missing[s] {
+		return math.NaN(), nil
+	}
+	if v, err := strconv.ParseFloat(s, 64); err == nil {
+		return v, nil
+	}
+	switch strings.ToLower(s) {
+	case "true":
+		return 1, nil
+	case "false":
+		return 0, nil
+	}
+	return 0, fmt.Errorf("cannot convert %q to a number", s)
+}
+
+// selectColumns returns the indices of the selected columns and of the key
+// columns among names. All columns but the keys are selected when none is.
+func selectColumns(names, selected, keys []string) ([]int, []int, error) {
+	index := make(map[string]int, len(names))
+	for j, name := range names {
+		if _, ok := index[name]; !ok {
+			index[name] = j
+		}
+	}
+	find := func(list []string) ([]int, error) {
+		indices := make([]int, len(list))
+		for k, name := range list {
+			j, ok := index[name]
+			if !ok {
+				return nil, fmt.Errorf("unknown column %q", name)
+			}
+			indices[k] = j
+		}
+		return indices, nil
+	}
+
+	keyIndices, err := find(keys)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(selected) > 0 {
+		indices, err := find(selected)
+		return indices, keyIndices, err
+	}
+	isKey := make(map[int]bool, len(keyIndices))
+	for _, j := range keyIndices {
+		isKey[j] = true
+	}
+	var indices []int
+	for j := range names {
+		if !isKey[j] {
+			indices = append(indices, j)
+		}
+	}
+	return indices, keyIndices, nil
+}
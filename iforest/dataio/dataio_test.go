@@ -0,0 +1,75 @@
+package dataio
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string][]byte{
+		"data.csv":     []byte("x,y\n1,2\n3,4\n"),
+		"data.tsv":     []byte("x\ty\n1\t2\n3\t4\n"),
+		"data.jsonl":   []byte("{\"x\": 1, \"y\": 2}\n{\"x\": 3, \"y\": 4}\n"),
+		"data.ndjson":  []byte("{\"y\": 2, \"x\": 1}\n{\"y\": 4, \"x\": 3}\n"),
+		"data.parquet": parquetData(t, 2),
+	}
+	for name, data := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), data, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for name := range files {
+
+		opts := Options{Columns: []string{"x", "y"}}
+		want := [][]float64{{1, 2}, {3, 4}}
+		if name == "data.parquet" {
+			opts.Columns = []string{"count", "total"}
+			want = [][]float64{{0, 0}, {1, 2}}
+		}
+		d, err := Load(filepath.Join(dir, name), opts)
+		if err != nil {
+			t.Errorf("Load(%v) error = %v", name, err)
+			continue
+		}
+		if !reflect.DeepEqual(d.Columns, opts.Columns) || !reflect.DeepEqual(d.X, want) {
+			t.Errorf("Load(%v) = %v %v, want %v %v", name, d.Columns, d.X, opts.Columns, want)
+		}
+
+	}
+
+	for _, name := range []string{"data.txt", "missing.csv"} {
+
+		if _, err := Load(filepath.Join(dir, name), Options{}); err == nil {
+			t.Errorf("Load(%v) error = nil, want an error", name)
+		}
+
+	}
+}
+
+func TestOpen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "data.csv")
+	if err := os.WriteFile(path, []byte("a,b\n1,2\n3,4\n5,6\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	it, err := Open(path, Options{Columns: []string{"b"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var sum float64
+	for it.Next() {
+		sum += it.Row()[0]
+	}
+	if err := it.Err(); err != nil {
+		t.Errorf("Iterator.Err() = %v", err)
+	}
+	if sum != 12 {
+		t.Errorf("sum of the rows = %v, want 12", sum)
+	}
+	if err := it.Close(); err != nil {
+		t.Errorf("Iterator.Close() = %v", err)
+	}
+}
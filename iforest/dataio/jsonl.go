@@ -0,0 +1,240 @@
+package dataio
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+)
+
+// jsonLinesIterator reads the objects of a JSON Lines file.
+type jsonLinesIterator struct {
+	reader  *bufio.Reader
+	columns []string
+	index   map[string]int
+	// keys maps the key columns to their position in the keys of a row.
+	keys map[string]int
+	// strict tells whether fields not found on the first line are errors.
+	strict  bool
+	missing map[string]bool
+	// first is the first object, read to name the columns.
+	first *jsonObject
+	line  int
+	row   []float64
+	key   []string
+	err   error
+}
+
+// jsonObject is a JSON object with ordered keys.
+type jsonObject struct {
+	keys   []string
+	values []interface{}
+}
+
+// NewJSONLinesIterator returns an iterator over the objects of a JSON Lines
+// file, one object per line. Without Options.Columns, the columns are the
+// fields of the first object but the keys, and other fields are errors. Absent
+// fields are missing values.
+func NewJSONLinesIterator(r io.Reader, opts Options) (Iterator, error) {
+	it := &jsonLinesIterator{reader: bufio.NewReader(r), missing: opts.missingSet()}
+	it.keys = make(map[string]int, len(opts.Keys))
+	for k, name := range opts.Keys {
+		if _, ok := it.keys[name]; ok {
+			return nil, fmt.Errorf("duplicate key %q", name)
+		}
+		it.keys[name] = k
+	}
+	it.columns = opts.Columns
+	if len(opts.Columns) == 0 {
+		obj, err := it.readObject()
+		if err == io.EOF {
+			return nil, fmt.Errorf("no data")
+		}
+		if err != nil {
+			return nil, err
+		}
+		it.first = obj
+		it.columns = nil
+		for _, name := range obj.keys {
+			if _, ok := it.keys[name]; !ok {
+				it.columns = append(it.columns, name)
+			}
+		}
+		it.strict = true
+	}
+	it.index = make(map[string]int, len(it.columns))
+	for k, name := range it.columns {
+		if _, ok := it.index[name]; ok {
+			return nil, fmt.Errorf("duplicate column %q", name)
+		}
+		it.index[name] = k
+	}
+	return it, nil
+}
+
+// ReadJSONLines reads a whole JSON Lines file.
+func ReadJSONLines(r io.Reader, opts Options) (*Dataset, error) {
+	it, err := NewJSONLinesIterator(r, opts)
+	if err != nil {
+		return nil, err
+	}
+	return Collect(it)
+}
+
+func (it *jsonLinesIterator) Columns() []string {
+	return it.columns
+}
+
+func (it *jsonLinesIterator) Next() bool {
+	if it.err != nil {
+		return false
+	}
+	obj := it.first
+	if obj != nil {
+		it.first = nil
+	} else if obj, it.err = it.readObject(); it.err != nil {
+		if it.err == io.EOF {
+			it.err = nil
+		}
+		return false
+	}
+
+	row := make([]float64, len(it.columns))
+	for k := range row {
+		row[k] = math.NaN()
+	}
+	var keys []string
+	if len(it.keys) > 0 {
+		keys = make([]string, len(it.keys))
+	}
+	for i, key := range obj.keys {
+		if k, ok := it.keys[key]; ok {
+			v, err := keyString(obj.values[i])
+			if err != nil {
+				it.err = fmt.Errorf("line %d, field %s: %v", it.line, key, err)
+				return false
+			}
+			keys[k] = v
+			continue
+		}
+		k, ok := it.index[key]
+		if !ok {
+			if it.strict {
+				it.err = fmt.Errorf("line %d: unexpected field %q", it.line, key)
+				return false
+			}
+			continue
+		}
+		v, err := it.convert(obj.values[i])
+		if err != nil {
+			it.err = fmt.Errorf("line %d, field %s: %v", it.line, key, err)
+			return false
+		}
+		row[k] = v
+	}
+	it.row = row
+	it.key = keys
+	return true
+}
+
+// convert converts a decoded JSON value.
+func (it *jsonLinesIterator) convert(v interface{}) (float64, error) {
+	switch v := v.(type) {
+	case nil:
+		return math.NaN(), nil
+	case json.Number:
+		return strconv.ParseFloat(string(v), 64)
+	case bool:
+		if v {
+			return 1, nil
+		}
+		return 0, nil
+	case string:
+		return parseString(v, it.missing)
+	}
+	return 0, errors.New("cannot convert an object or an array to a number")
+}
+
+// keyString converts a decoded JSON value of a key column.
+func keyString(v interface{}) (string, error) {
+	switch v := v.(type) {
+	case nil:
+		return "", nil
+	case json.Number:
+		return string(v), nil
+	case bool:
+		return strconv.FormatBool(v), nil
+	case string:
+		return v, nil
+	}
+	return "", errors.New("a key cannot be an object or an array")
+}
+
+// readObject reads the object of the next non blank line.
+func (it *jsonLinesIterator) readObject() (*jsonObject, error) {
+	for {
+		line, err := it.reader.ReadBytes('\n')
+		if err != nil && (err != io.EOF || len(line) == 0) {
+			return nil, err
+		}
+		it.line++
+		line = bytes.TrimSpace(line)
+		if len(line) == 0 {
+			continue
+		}
+		obj, derr := decodeObject(line)
+		if derr != nil {
+			return nil, fmt.Errorf("line %d: %v", it.line, derr)
+		}
+		return obj, nil
+	}
+}
+
+// decodeObject decodes a JSON object keeping the order of its keys.
+func decodeObject(data []byte) (*jsonObject, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	if tok, err := dec.Token(); err != nil || tok != json.Delim('{') {
+		return nil, errors.New("not a JSON object")
+	}
+	obj := &jsonObject{}
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return nil, err
+		}
+		var v interface{}
+		if err := dec.Decode(&v); err != nil {
+			return nil, err
+		}
+		obj.keys = append(obj.keys, tok.(string))
+		obj.values = append(obj.values, v)
+	}
+	if _, err := dec.Token(); err != nil {
+		return nil, err
+	}
+	if dec.More() {
+		return nil, errors.New("data after the JSON object")
+	}
+	return obj, nil
+}
+
+func (it *jsonLinesIterator) Row() []float64 {
+	return it.row
+}
+
+func (it *jsonLinesIterator) Keys() []string {
+	return it.key
+}
+
+func (it *jsonLinesIterator) Err() error {
+	return it.err
+}
+
+func (it *jsonLinesIterator) Close() error {
+	return nil
+}
@@ -0,0 +1,67 @@
+package dataio
+
+import (
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestReadJSONLines(t *testing.T) {
+	nan := math.NaN()
+	tests := []struct {
+		name        string
+		data        string
+		opts        Options
+		wantColumns []string
+		wantX       [][]float64
+		wantKeys    [][]string
+		wantErr     bool
+	}{
+		{
+			name:        "fields of the first line",
+			data:        "{\"z\": 1, \"a\": 2.5}\n\n{\"a\": 3, \"z\": null}\n{\"z\": \"4\"}",
+			wantColumns: []string{"z", "a"},
+			wantX:       [][]float64{{1, 2.5}, {nan, 3}, {4, nan}},
+		},
+		{
+			name:        "selected columns",
+			data:        "{\"id\": \"r1\", \"x\": 1, \"y\": true}\n{\"id\": \"r2\", \"x\": \"NA\", \"other\": [1]}\n",
+			opts:        Options{Columns: []string{"y", "x"}},
+			wantColumns: []string{"y", "x"},
+			wantX:       [][]float64{{1, 1}, {nan, nan}},
+		},
+		{
+			name:        "keys",
+			data:        "{\"id\": \"r1\", \"x\": 1, \"n\": 2}\n{\"x\": 3, \"n\": true}\n",
+			opts:        Options{Keys: []string{"id", "n"}},
+			wantColumns: []string{"x"},
+			wantX:       [][]float64{{1}, {3}},
+			wantKeys:    [][]string{{"r1", "2"}, {"", "true"}},
+		},
+		{name: "empty", data: "\n", wantErr: true},
+		{name: "object key", data: "{\"id\": {}, \"a\": 1}\n", opts: Options{Keys: []string{"id"}}, wantErr: true},
+		{name: "not an object", data: "[1, 2]\n", wantErr: true},
+		{name: "invalid JSON", data: "{\"a\": 1\n", wantErr: true},
+		{name: "trailing data", data: "{\"a\": 1} 2\n", wantErr: true},
+		{name: "unexpected field", data: "{\"a\": 1}\n{\"b\": 2}\n", wantErr: true},
+		{name: "duplicate field", data: "{\"a\": 1, \"a\": 2}\n", wantErr: true},
+		{name: "array value", data: "{\"a\": [1]}\n", wantErr: true},
+		{name: "not a number", data: "{\"a\": \"x\"}\n", wantErr: true},
+	}
+	for _, tt := range tests {
+
+		d, err := ReadJSONLines(strings.NewReader(tt.data), tt.opts)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: ReadJSONLines() error = %v, wantErr %v", tt.name, err, tt.wantErr)
+			continue
+		}
+		if err != nil {
+			continue
+		}
+		if !reflect.DeepEqual(d.Columns, tt.wantColumns) || !equalRows(d.X, tt.wantX) || !reflect.DeepEqual(d.Keys, tt.wantKeys) {
+			t.Errorf("%s: ReadJSONLines() = %v %v %v, want %v %v %v", tt.name, d.Columns, d.X, d.Keys, tt.wantColumns, tt.wantX, tt.wantKeys)
+		}
+
+	}
+}
@@ -0,0 +1,257 @@
+package dataio
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strings"
+
+	"github.com/xitongsys/parquet-go/common"
+	"github.com/xitongsys/parquet-go/reader"
+	"github.com/xitongsys/parquet-go/source"
+)
+
+// parquetIterator reads a Parquet file by batches of rows.
+type parquetIterator struct {
+	reader  *reader.ParquetReader
+	columns []string
+	// keys are the names of the key columns, read after the columns.
+	keys    []string
+	paths   []string
+	missing map[string]bool
+	// remaining is the number of rows not read yet.
+	remaining int64
+	batchSize int64
+	// batch holds the values of each column for the current batch.
+	batch [][]interface{}
+	pos   int
+	rows  int64
+	// read is the number of rows returned by Next.
+	read int64
+	row  []float64
+	key  []string
+	err  error
+}
+
+// NewParquetIterator returns an iterator over the rows of a Parquet file of
+// given size. Columns are named by their path, with nested fields separated
+// by dots. Repeated columns cannot be read and are skipped when
+// Options.Columns is empty. Logical types such as dates, timestamps or
+// decimals are read as their physical integer values.
+func NewParquetIterator(r io.ReaderAt, size int64, opts Options) (Iterator, error) {
+	pr, err := reader.NewParquetColumnReader(newParquetFile(r, size), 1)
+	if err != nil {
+		return nil, err
+	}
+
+	sh := pr.SchemaHandler
+	var names, paths []string
+	repeated := make(map[string]bool)
+	for _, path := range sh.ValueColumns {
+		exPath := common.StrToPath(sh.InPathToExPath[path])
+		name := strings.Join(exPath[1:], ".")
+		if rl, err := sh.MaxRepetitionLevel(common.StrToPath(path)); err != nil || rl > 0 {
+			repeated[name] = true
+			if len(opts.Columns) == 0 {
+				continue
+			}
+		}
+		names = append(names, name)
+		paths = append(paths, path)
+	}
+	indices, keys, err := selectColumns(names, opts.Columns, opts.Keys)
+	if err != nil {
+		return nil, err
+	}
+
+	it := &parquetIterator{
+		reader:    pr,
+		missing:   opts.missingSet(),
+		remaining: pr.GetNumRows(),
+		batchSize: int64(opts.BatchSize),
+	}
+	if it.batchSize <= 0 {
+		it.batchSize = 1024
+	}
+	for _, j := range indices {
+		if repeated[names[j]] {
+			return nil, fmt.Errorf("column %q is repeated", names[j])
+		}
+		it.columns = append(it.columns, names[j])
+		it.paths = append(it.paths, paths[j])
+	}
+	if len(it.columns) == 0 {
+		return nil, errors.New("no column")
+	}
+	for _, j := range keys {
+		if repeated[names[j]] {
+			return nil, fmt.Errorf("column %q is repeated", names[j])
+		}
+		it.keys = append(it.keys, names[j])
+		it.paths = append(it.paths, paths[j])
+	}
+	return it, nil
+}
+
+// ReadParquet reads a whole Parquet file of given size.
+func ReadParquet(r io.ReaderAt, size int64, opts Options) (*Dataset, error) {
+	it, err := NewParquetIterator(r, size, opts)
+	if err != nil {
+		return nil, err
+	}
+	d, err := Collect(it)
+	if cerr := it.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+func (it *parquetIterator) Columns() []string {
+	return it.columns
+}
+
+func (it *parquetIterator) Next() bool {
+	if it.err != nil {
+		return false
+	}
+	if int64(it.pos) == it.rows {
+		if it.remaining == 0 {
+			return false
+		}
+		if it.err = it.readBatch(); it.err != nil {
+			return false
+		}
+	}
+
+	row := make([]float64, len(it.columns))
+	var keys []string
+	if len(it.keys) > 0 {
+		keys = make([]string, len(it.keys))
+	}
+	for k, values := range it.batch {
+		if k >= len(row) {
+			if v := values[it.pos]; v != nil {
+				keys[k-len(row)] = fmt.Sprint(v)
+			}
+			continue
+		}
+		v, err := it.convert(values[it.pos])
+		if err != nil {
+			it.err = fmt.Errorf("row %d, column %s: %v", it.read+1, it.columns[k], err)
+			return false
+		}
+		row[k] = v
+	}
+	it.pos++
+	it.read++
+	it.row = row
+	it.key = keys
+	return true
+}
+
+// readBatch reads the values of the next batch of rows.
+func (it *parquetIterator) readBatch() error {
+	n := it.batchSize
+	if n > it.remaining {
+		n = it.remaining
+	}
+	if it.batch == nil {
+		it.batch = make([][]interface{}, len(it.paths))
+	}
+	for k, path := range it.paths {
+		values, _, _, err := it.reader.ReadColumnByPath(path, n)
+		if err != nil {
+			return err
+		}
+		if int64(len(values)) != n {
+			return fmt.Errorf("column %s: %d values read, want %d", it.name(k), len(values), n)
+		}
+		it.batch[k] = values
+	}
+	it.remaining -= n
+	it.rows = n
+	it.pos = 0
+	return nil
+}
+
+// name returns the name of the k-th column read, the keys following the
+// columns.
+func (it *parquetIterator) name(k int) string {
+	if k < len(it.columns) {
+		return it.columns[k]
+	}
+	return it.keys[k-len(it.columns)]
+}
+
+// convert converts a Parquet value.
+func (it *parquetIterator) convert(v interface{}) (float64, error) {
+	switch v := v.(type) {
+	case nil:
+		return math.NaN(), nil
+	case bool:
+		if v {
+			return 1, nil
+		}
+		return 0, nil
+	case int32:
+		return float64(v), nil
+	case int64:
+		return float64(v), nil
+	case float32:
+		return float64(v), nil
+	case float64:
+		return v, nil
+	case string:
+		return parseString(v, it.missing)
+	}
+	return 0, fmt.Errorf("cannot convert %T to a number", v)
+}
+
+func (it *parquetIterator) Row() []float64 {
+	return it.row
+}
+
+func (it *parquetIterator) Keys() []string {
+	return it.key
+}
+
+func (it *parquetIterator) Err() error {
+	return it.err
+}
+
+func (it *parquetIterator) Close() error {
+	it.reader.ReadStop()
+	return nil
+}
+
+// parquetFile adapts an io.ReaderAt to the files of the Parquet reader, which
+// opens one file per column.
+type parquetFile struct {
+	*io.SectionReader
+	r    io.ReaderAt
+	size int64
+}
+
+func newParquetFile(r io.ReaderAt, size int64) *parquetFile {
+	return &parquetFile{SectionReader: io.NewSectionReader(r, 0, size), r: r, size: size}
+}
+
+func (f *parquetFile) Open(string) (source.ParquetFile, error) {
+	return newParquetFile(f.r, f.size), nil
+}
+
+func (f *parquetFile) Create(string) (source.ParquetFile, error) {
+	return nil, errors.New("parquet files are read only")
+}
+
+func (f *parquetFile) Write([]byte) (int, error) {
+	return 0, errors.New("parquet files are read only")
+}
+
+func (f *parquetFile) Close() error {
+	return nil
+}
@@ -0,0 +1,109 @@
+package dataio
+
+import (
+	"bytes"
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/xitongsys/parquet-go/writer"
+)
+
+type parquetRecord struct {
+	Name   string   `parquet:"name=name, type=BYTE_ARRAY, convertedtype=UTF8"`
+	Count  int32    `parquet:"name=count, type=INT32"`
+	Total  int64    `parquet:"name=total, type=INT64"`
+	Weight float32  `parquet:"name=weight, type=FLOAT"`
+	Value  *float64 `parquet:"name=value, type=DOUBLE, repetitiontype=OPTIONAL"`
+	Flag   bool     `parquet:"name=flag, type=BOOLEAN"`
+	Tags   []int32  `parquet:"name=tags, type=INT32, repetitiontype=REPEATED"`
+}
+
+// parquetData writes n records to a Parquet file, every third value being
+// null.
+func parquetData(t *testing.T, n int) []byte {
+	var b bytes.Buffer
+	w, err := writer.NewParquetWriterFromWriter(&b, new(parquetRecord), 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.PageSize = 256
+	for i := 0; i < n; i++ {
+		r := parquetRecord{
+			Name:   "1.5",
+			Count:  int32(i),
+			Total:  int64(2 * i),
+			Weight: float32(i) / 4,
+			Flag:   i%2 == 0,
+			Tags:   []int32{int32(i)},
+		}
+		if i%3 != 0 {
+			v := float64(i) + 0.5
+			r.Value = &v
+		}
+		if err := w.Write(r); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.WriteStop(); err != nil {
+		t.Fatal(err)
+	}
+	return b.Bytes()
+}
+
+func TestReadParquet(t *testing.T) {
+	data := parquetData(t, 2500)
+	r := bytes.NewReader(data)
+
+	d, err := ReadParquet(r, int64(len(data)), Options{BatchSize: 1000})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"name", "count", "total", "weight", "value", "flag"}; !reflect.DeepEqual(d.Columns, want) {
+		t.Errorf("ReadParquet() columns = %v, want %v", d.Columns, want)
+	}
+	if len(d.X) != 2500 {
+		t.Fatalf("ReadParquet() read %v rows, want 2500", len(d.X))
+	}
+	for _, i := range []int{0, 1, 999, 1000, 2499} {
+		value := math.NaN()
+		if i%3 != 0 {
+			value = float64(i) + 0.5
+		}
+		flag := 0.0
+		if i%2 == 0 {
+			flag = 1
+		}
+		if want := [][]float64{{1.5, float64(i), float64(2 * i), float64(i) / 4, value, flag}}; !equalRows(d.X[i:i+1], want) {
+			t.Errorf("ReadParquet() row %v = %v, want %v", i, d.X[i], want[0])
+		}
+	}
+
+	d, err = ReadParquet(r, int64(len(data)), Options{Columns: []string{"value", "count"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(d.Columns, []string{"value", "count"}) || len(d.X) != 2500 || !equalRows(d.X[1:2], [][]float64{{1.5, 1}}) {
+		t.Errorf("ReadParquet() with columns = %v, %v rows, second %v", d.Columns, len(d.X), d.X[1])
+	}
+
+	d, err = ReadParquet(r, int64(len(data)), Options{Columns: []string{"count"}, Keys: []string{"value", "name"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := [][]string{{"", "1.5"}, {"1.5", "1.5"}}; !reflect.DeepEqual(d.Keys[:2], want) || !equalRows(d.X[1:2], [][]float64{{1}}) {
+		t.Errorf("ReadParquet() with keys = %v, second row %v, want %v", d.Keys[:2], d.X[1], want)
+	}
+
+	for _, columns := range [][]string{{"unknown"}, {"tags"}} {
+
+		if _, err := ReadParquet(r, int64(len(data)), Options{Columns: columns}); err == nil {
+			t.Errorf("ReadParquet() with columns %v error = nil, want an error", columns)
+		}
+
+	}
+	if _, err := ReadParquet(strings.NewReader("not a parquet file"), 18, Options{}); err == nil {
+		t.Errorf("ReadParquet() on invalid data error = nil, want an error")
+	}
+}
@@ -0,0 +1,131 @@
+package iforest
+
+import (
+	"errors"
+	"math"
+
+	"github.com/e-XpertSolutions/go-iforest/v2/iforest/eval"
+)
+
+// ConvergenceOptions controls the convergence diagnostic.
+type ConvergenceOptions struct {
+	// Confidence is the confidence level of the score intervals, 0.95 by
+	// default.
+	Confidence float64
+	// Target is the rank correlation with the whole forest from which rankings
+	// are considered stable, 0.99 by default.
+	Target float64
+}
+
+// Convergence describes how the scores of a dataset evolve as trees are added
+// to the forest, in the order of Forest.Trees.
+type Convergence struct {
+	// PathMean and PathStd are the mean and the standard deviation of the path
+	// lengths of each vector over all trees.
+	PathMean []float64
+	PathStd  []float64
+	// ScoreLow and ScoreHigh bound the confidence interval of the score of
+	// each vector, derived from the standard error of its mean path length.
+	ScoreLow  []float64
+	ScoreHigh []float64
+	// StdError[t] is the standard error of the mean path length over the t+1
+	// first trees, averaged over the vectors.
+	StdError []float64
+	// RankCorrelation[t] is the Spearman's rank correlation between the scores
+	// computed with the t+1 first trees and the scores of the whole forest.
+	RankCorrelation []float64
+	// SuggestedTrees is the number of trees from which the rank correlation
+	// stays above the target.
+	SuggestedTrees int
+}
+
+// Convergence computes the convergence diagnostic of the forest on given
+// dataset, which helps to choose the number of trees.
+func (f *Forest) Convergence(X [][]float64, opts ConvergenceOptions) (*Convergence, error) {
+	if !f.Trained {
+		return nil, errors.New("cannot compute convergence - model has not been trained yet")
+	}
+	if len(X) < 2 {
+		return nil, errors.New("cannot compute convergence - not enough data")
+	}
+	confidence := opts.Confidence
+	if confidence == 0 {
+		confidence = 0.95
+	}
+	target := opts.Target
+	if target == 0 {
+		target = 0.99
+	}
+	if confidence <= 0 || confidence >= 1 {
+		return nil, errors.New("confidence level must be between 0 and 1")
+	}
+
+	nbTrees := len(f.Trees)
+	paths := make([][]float64, len(X))
+	for i := range X {
+		paths[i], _ = f.PathLengths(X[i])
+	}
+
+	c := &Convergence{
+		PathMean:        make([]float64, len(X)),
+		PathStd:         make([]float64, len(X)),
+		ScoreLow:        make([]float64, len(X)),
+		ScoreHigh:       make([]float64, len(X)),
+		StdError:        make([]float64, nbTrees),
+		RankCorrelation: make([]float64, nbTrees),
+	}
+
+	// the final means are computed as the running ones below, so that both
+	// agree exactly once all trees are added
+	final := make([]float64, len(X))
+	for i := range X {
+		for t, p := range paths[i] {
+			final[i] += (p - final[i]) / float64(t+1)
+		}
+	}
+
+	// running means and sums of squared deviations (Welford's algorithm),
+	// scores are a monotonic function of the mean path length, which has the
+	// same ranking
+	mean := make([]float64, len(X))
+	m2 := make([]float64, len(X))
+	c.SuggestedTrees = nbTrees + 1
+	for t := 0; t < nbTrees; t++ {
+		for i := range X {
+			delta := paths[i][t] - mean[i]
+			mean[i] += delta / float64(t+1)
+			m2[i] += delta * (paths[i][t] - mean[i])
+			if t > 0 {
+				c.StdError[t] += math.Sqrt(m2[i]/float64(t)) / math.Sqrt(float64(t+1))
+			}
+		}
+		c.StdError[t] /= float64(len(X))
+
+		rho, err := eval.Spearman(mean, final)
+		if err != nil {
+			// constant scores cannot be ranked
+			rho = 0
+		}
+		c.RankCorrelation[t] = rho
+		if rho < target {
+			c.SuggestedTrees = nbTrees + 1
+		} else if c.SuggestedTrees > nbTrees {
+			c.SuggestedTrees = t + 1
+		}
+	}
+	if c.SuggestedTrees > nbTrees {
+		c.SuggestedTrees = nbTrees
+	}
+
+	z := math.Sqrt2 * math.Erfinv(confidence)
+	for i := range X {
+		c.PathMean[i] = final[i]
+		if nbTrees > 1 {
+			c.PathStd[i] = math.Sqrt(m2[i] / float64(nbTrees-1))
+		}
+		margin := z * c.PathStd[i] / math.Sqrt(float64(nbTrees))
+		c.ScoreLow[i] = f.PathScore(final[i] - margin)
+		c.ScoreHigh[i] = f.PathScore(final[i] + margin)
+	}
+	return c, nil
+}
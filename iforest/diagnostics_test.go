@@ -0,0 +1,58 @@
+package iforest
+
+import (
+	"math"
+	"testing"
+)
+
+func TestForest_Convergence(t *testing.T) {
+	_, X := randomData32(300, 3)
+
+	f := NewForest(100, 64, 0.05)
+	f.Seed = 1
+	if _, err := f.Convergence(X, ConvergenceOptions{}); err == nil {
+		t.Errorf("Forest.Convergence() should fail before training")
+	}
+	f.Train(X)
+	f.Test(X)
+
+	c, err := f.Convergence(X, ConvergenceOptions{})
+	if err != nil {
+		t.Fatalf("Forest.Convergence() error = %v", err)
+	}
+	if len(c.RankCorrelation) != 100 || len(c.StdError) != 100 || len(c.PathMean) != len(X) {
+		t.Fatalf("Forest.Convergence() = %v correlations, %v errors, %v means", len(c.RankCorrelation), len(c.StdError), len(c.PathMean))
+	}
+	if rho := c.RankCorrelation[99]; math.Abs(rho-1) > 1e-12 {
+		t.Errorf("Forest.Convergence() last correlation = %v, want 1", rho)
+	}
+	if c.SuggestedTrees < 1 || c.SuggestedTrees > 100 {
+		t.Errorf("Forest.Convergence() suggests %v trees", c.SuggestedTrees)
+	}
+	for k := c.SuggestedTrees - 1; k < 100; k++ {
+		if c.RankCorrelation[k] < 0.99 {
+			t.Errorf("Forest.Convergence() correlation with %v trees = %v, below target after suggested %v", k+1, c.RankCorrelation[k], c.SuggestedTrees)
+		}
+	}
+	if c.StdError[99] >= c.StdError[9] {
+		t.Errorf("Forest.Convergence() standard error does not decrease: %v with 10 trees, %v with 100", c.StdError[9], c.StdError[99])
+	}
+
+	_, scores, _ := f.Predict(X)
+	for i := range X {
+		if math.Abs(0.5-math.Pow(2, -c.PathMean[i]/f.CSubSampl)-scores[i]) > 1e-12 {
+			t.Errorf("Forest.Convergence() mean path %v does not match score %v", c.PathMean[i], scores[i])
+		}
+		if scores[i] < c.ScoreLow[i] || scores[i] > c.ScoreHigh[i] {
+			t.Errorf("Forest.Convergence() interval [%v, %v] does not contain score %v", c.ScoreLow[i], c.ScoreHigh[i], scores[i])
+		}
+	}
+
+	strict, _ := f.Convergence(X, ConvergenceOptions{Target: 0.9999})
+	if strict.SuggestedTrees < c.SuggestedTrees {
+		t.Errorf("Forest.Convergence() suggests %v trees for a stricter target, %v otherwise", strict.SuggestedTrees, c.SuggestedTrees)
+	}
+	if _, err := f.Convergence(X, ConvergenceOptions{Confidence: 1}); err == nil {
+		t.Errorf("Forest.Convergence() with confidence 1 should fail")
+	}
+}
@@ -0,0 +1,275 @@
+// Package distributed trains an isolation forest on a dataset partitioned
+// across processes or hosts, over net/rpc.
+//
+// Workers hold a shard of the data each and build trees from subsamples of
+// their shard. The coordinator shares the trees among the workers in
+// proportion to the size of their shards, asks them for batches of trees
+// built with seeds derived from its own, and assembles the trees it receives
+// into a Forest. Training with the same seed and the same workers, in the same
+// order, builds the same forest.
+package distributed
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand"
+	"net"
+	"net/rpc"
+	"sync"
+
+	"github.com/e-XpertSolutions/go-iforest/v2/iforest"
+)
+
+// Worker holds a shard of the data and builds trees from it. Its methods are
+// called by the coordinator over net/rpc.
+type Worker struct {
+	X [][]float64
+}
+
+// NewWorker initializes a worker holding given shard of the data.
+func NewWorker(X [][]float64) *Worker {
+	return &Worker{X: X}
+}
+
+// InfoArgs are the arguments of Worker.Info.
+type InfoArgs struct{}
+
+// Info describes the shard of a worker.
+type Info struct {
+	Rows     int
+	Features int
+}
+
+// Info describes the shard of the worker.
+func (w *Worker) Info(args *InfoArgs, reply *Info) error {
+	reply.Rows = len(w.X)
+	if len(w.X) > 0 {
+		reply.Features = len(w.X[0])
+	}
+	return nil
+}
+
+// BuildArgs are the arguments of Worker.Build.
+type BuildArgs struct {
+	NbTrees         int
+	SubsamplingSize int
+	Seed            int64
+}
+
+// BuildReply holds the trees built by a worker.
+type BuildReply struct {
+	// Trees are the trees encoded in JSON, as saved by Forest.Save.
+	Trees []byte
+	// CSubSampl is the average path length of the subsamples the trees were
+	// built from, which are smaller than asked when the shard is.
+	CSubSampl float64
+}
+
+// Build builds trees from subsamples of the shard of the worker.
+func (w *Worker) Build(args *BuildArgs, reply *BuildReply) error {
+	if len(w.X) < 2 {
+		return errors.New("cannot build trees - not enough data")
+	}
+	if args.NbTrees < 1 || args.SubsamplingSize < 2 {
+		return errors.New("cannot build trees - invalid parameters")
+	}
+	size := args.SubsamplingSize
+	if size > len(w.X) {
+		size = len(w.X)
+	}
+	f := iforest.NewForest(args.NbTrees, size, 0)
+	f.Seed = args.Seed
+	f.Train(w.X)
+
+	trees, err := json.Marshal(f.Trees)
+	if err != nil {
+		return err
+	}
+	reply.Trees = trees
+	reply.CSubSampl = f.CSubSampl
+	return nil
+}
+
+// NewServer returns an RPC server exposing the worker.
+func NewServer(w *Worker) (*rpc.Server, error) {
+	s := rpc.NewServer()
+	if err := s.RegisterName("Worker", w); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Serve accepts connections of coordinators on the listener and serves the
+// worker to them. It blocks until the listener is closed.
+func Serve(l net.Listener, w *Worker) error {
+	s, err := NewServer(w)
+	if err != nil {
+		return err
+	}
+	s.Accept(l)
+	return nil
+}
+
+// Options controls the distributed training.
+type Options struct {
+	NbTrees         int
+	SubsamplingSize int
+	AnomalyRatio    float64
+	// Seed drives the seeds of the workers. When 0, it is drawn from the
+	// top-level functions of math/rand.
+	Seed int64
+	// BatchSize is the number of trees asked at once to a worker, 10 by
+	// default.
+	BatchSize int
+}
+
+// Train builds a forest with the workers served to the clients. The forest is
+// trained but not tested.
+func Train(clients []*rpc.Client, opts Options) (*iforest.Forest, error) {
+	if len(clients) == 0 {
+		return nil, errors.New("cannot train - no worker")
+	}
+	if opts.NbTrees < 1 || opts.SubsamplingSize < 2 {
+		return nil, errors.New("cannot train - invalid parameters")
+	}
+	batchSize := opts.BatchSize
+	if batchSize <= 0 {
+		batchSize = 10
+	}
+	seed := opts.Seed
+	if seed == 0 {
+		seed = rand.Int63()
+	}
+
+	infos := make([]Info, len(clients))
+	var rows, features int
+	for k, c := range clients {
+		if err := c.Call("Worker.Info", &InfoArgs{}, &infos[k]); err != nil {
+			return nil, fmt.Errorf("cannot train - worker %d: %v", k, err)
+		}
+		if infos[k].Rows == 0 {
+			continue
+		}
+		if features == 0 {
+			features = infos[k].Features
+		}
+		if infos[k].Features != features {
+			return nil, fmt.Errorf("cannot train - worker %d has %d features, want %d", k, infos[k].Features, features)
+		}
+		if infos[k].Rows >= 2 {
+			rows += infos[k].Rows
+		}
+	}
+	if rows == 0 {
+		return nil, errors.New("cannot train - workers have not enough data")
+	}
+
+	f := iforest.NewForest(opts.NbTrees, opts.SubsamplingSize, opts.AnomalyRatio)
+	counts := shareTrees(opts.NbTrees, infos)
+	trees := make([][]iforest.Tree, len(clients))
+	errs := make([]error, len(clients))
+	var wg sync.WaitGroup
+	for k := range clients {
+		wg.Add(1)
+		go func(k int) {
+			defer wg.Done()
+			for batch := 0; len(trees[k]) < counts[k]; batch++ {
+				n := counts[k] - len(trees[k])
+				if n > batchSize {
+					n = batchSize
+				}
+				args := &BuildArgs{
+					NbTrees:         n,
+					SubsamplingSize: opts.SubsamplingSize,
+					Seed:            deriveSeed(seed, k, batch),
+				}
+				var reply BuildReply
+				if err := clients[k].Call("Worker.Build", args, &reply); err != nil {
+					errs[k] = fmt.Errorf("cannot train - worker %d: %v", k, err)
+					return
+				}
+				var built []iforest.Tree
+				if err := json.Unmarshal(reply.Trees, &built); err != nil {
+					errs[k] = fmt.Errorf("cannot train - worker %d: %v", k, err)
+					return
+				}
+				if len(built) != args.NbTrees || reply.CSubSampl <= 0 {
+					errs[k] = fmt.Errorf("cannot train - worker %d sent invalid trees", k)
+					return
+				}
+				for _, t := range built {
+					// trees built from smaller subsamples are normalised
+					// by their own average path length
+					if reply.CSubSampl != f.CSubSampl {
+						t.Scale = f.CSubSampl / reply.CSubSampl
+					}
+					trees[k] = append(trees[k], t)
+				}
+			}
+		}(k)
+	}
+	wg.Wait()
+	for _, err := range errs {
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	f.Trees = f.Trees[:0]
+	for k := range trees {
+		f.Trees = append(f.Trees, trees[k]...)
+	}
+	f.NbFeatures = features
+	f.Trained = true
+	return f, nil
+}
+
+// shareTrees shares the trees among the workers in proportion to the number of
+// rows of their shards, with the largest remainder method. Shards with less
+// than 2 rows cannot build trees and get none.
+func shareTrees(nbTrees int, infos []Info) []int {
+	var rows int
+	for _, info := range infos {
+		if info.Rows >= 2 {
+			rows += info.Rows
+		}
+	}
+	counts := make([]int, len(infos))
+	remainders := make([]float64, len(infos))
+	left := nbTrees
+	for k, info := range infos {
+		if info.Rows < 2 {
+			continue
+		}
+		share := float64(nbTrees) * float64(info.Rows) / float64(rows)
+		counts[k] = int(share)
+		remainders[k] = share - float64(counts[k])
+		left -= counts[k]
+	}
+	for ; left > 0; left-- {
+		best := -1
+		for k := range infos {
+			if infos[k].Rows >= 2 && (best < 0 || remainders[k] > remainders[best]) {
+				best = k
+			}
+		}
+		counts[best]++
+		remainders[best] = -1
+	}
+	return counts
+}
+
+// deriveSeed derives the seed of a batch of trees of a worker from the seed of
+// the training, with the finalizer of splitmix64. It is never 0, which would
+// make the worker use the top-level functions of math/rand.
+func deriveSeed(seed int64, worker, batch int) int64 {
+	z := uint64(seed) + 0x9e3779b97f4a7c15*uint64(worker+1) + 0xbf58476d1ce4e5b9*uint64(batch+1)
+	z = (z ^ (z >> 30)) * 0xbf58476d1ce4e5b9
+	z = (z ^ (z >> 27)) * 0x94d049bb133111eb
+	z ^= z >> 31
+	if z == 0 {
+		z = 1
+	}
+	return int64(z)
+}
@@ -0,0 +1,175 @@
+package distributed
+
+import (
+	"math/rand"
+	"net"
+	"net/rpc"
+	"reflect"
+	"testing"
+)
+
+func shardedData(sizes []int, cols int) [][][]float64 {
+	rnd := rand.New(rand.NewSource(1))
+	shards := make([][][]float64, len(sizes))
+	for s, n := range sizes {
+		shards[s] = make([][]float64, n)
+		for i := range shards[s] {
+			shards[s][i] = make([]float64, cols)
+			for j := range shards[s][i] {
+				shards[s][i][j] = rnd.NormFloat64()
+				if i%50 == 0 {
+					shards[s][i][j] += 8
+				}
+			}
+		}
+	}
+	return shards
+}
+
+// startWorkers serves a worker per shard on in-process listeners and returns
+// clients connected to them.
+func startWorkers(t *testing.T, shards [][][]float64) []*rpc.Client {
+	clients := make([]*rpc.Client, len(shards))
+	for s := range shards {
+		l, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatalf("net.Listen() error = %v", err)
+		}
+		go Serve(l, NewWorker(shards[s]))
+		c, err := rpc.Dial("tcp", l.Addr().String())
+		if err != nil {
+			t.Fatalf("rpc.Dial() error = %v", err)
+		}
+		t.Cleanup(func() {
+			c.Close()
+			l.Close()
+		})
+		clients[s] = c
+	}
+	return clients
+}
+
+func TestTrain(t *testing.T) {
+	shards := shardedData([]int{300, 200, 100}, 3)
+	clients := startWorkers(t, shards)
+
+	opts := Options{NbTrees: 30, SubsamplingSize: 128, AnomalyRatio: 0.02, Seed: 7, BatchSize: 4}
+	f, err := Train(clients, opts)
+	if err != nil {
+		t.Fatalf("Train() error = %v", err)
+	}
+	if len(f.Trees) != 30 || f.NbTrees != 30 || f.NbFeatures != 3 || !f.Trained {
+		t.Fatalf("Train() = %v trees, %v features, trained %v", len(f.Trees), f.NbFeatures, f.Trained)
+	}
+	for j, tree := range f.Trees {
+		// the last 5 trees come from the shard smaller than the subsamples
+		if want := j >= 25; (tree.Scale != 0) != want {
+			t.Errorf("Train() tree %d scale = %v", j, tree.Scale)
+		}
+	}
+
+	var X [][]float64
+	for _, shard := range shards {
+		X = append(X, shard...)
+	}
+	if err := f.Test(X); err != nil {
+		t.Fatalf("Forest.Test() error = %v", err)
+	}
+	labels, scores, _ := f.Predict(X)
+	for i := range X {
+		if i%50 == 0 && labels[i] != 1 {
+			t.Errorf("Forest.Predict() row %d = %v with score %v, want anomaly", i, labels[i], scores[i])
+		}
+	}
+
+	g, err := Train(clients, opts)
+	if err != nil {
+		t.Fatalf("Train() error = %v", err)
+	}
+	g.Test(X)
+	_, again, _ := g.Predict(X)
+	if !reflect.DeepEqual(scores, again) {
+		t.Errorf("Train() with the same seed gives different scores")
+	}
+}
+
+func TestTrainErrors(t *testing.T) {
+	clients := startWorkers(t, shardedData([]int{100, 100}, 3))
+	mismatched := startWorkers(t, append(shardedData([]int{100}, 3), shardedData([]int{100}, 2)...))
+	empty := startWorkers(t, shardedData([]int{0, 0}, 3))
+	tiny := startWorkers(t, shardedData([]int{1, 1}, 3))
+
+	tests := []struct {
+		name    string
+		clients []*rpc.Client
+		opts    Options
+	}{
+		{name: "no worker", opts: Options{NbTrees: 10, SubsamplingSize: 32}},
+		{name: "no tree", clients: clients, opts: Options{SubsamplingSize: 32}},
+		{name: "subsample", clients: clients, opts: Options{NbTrees: 10, SubsamplingSize: 1}},
+		{name: "features", clients: mismatched, opts: Options{NbTrees: 10, SubsamplingSize: 32}},
+		{name: "no data", clients: empty, opts: Options{NbTrees: 10, SubsamplingSize: 32}},
+		{name: "tiny shards", clients: tiny, opts: Options{NbTrees: 10, SubsamplingSize: 32}},
+	}
+	for _, tt := range tests {
+
+		if _, err := Train(tt.clients, tt.opts); err == nil {
+			t.Errorf("%s: Train() should fail", tt.name)
+		}
+
+	}
+}
+
+func TestTrain_tinyShard(t *testing.T) {
+	shards := shardedData([]int{100, 1}, 3)
+	clients := startWorkers(t, shards)
+
+	f, err := Train(clients, Options{NbTrees: 200, SubsamplingSize: 32, Seed: 3})
+	if err != nil {
+		t.Fatalf("Train() error = %v", err)
+	}
+	if len(f.Trees) != 200 {
+		t.Errorf("Train() = %v trees, want 200", len(f.Trees))
+	}
+}
+
+func Test_shareTrees(t *testing.T) {
+	tests := []struct {
+		nbTrees int
+		rows    []int
+		want    []int
+	}{
+		{nbTrees: 30, rows: []int{300, 200, 100}, want: []int{15, 10, 5}},
+		{nbTrees: 10, rows: []int{2, 2, 2}, want: []int{4, 3, 3}},
+		{nbTrees: 10, rows: []int{1, 30, 10}, want: []int{0, 8, 2}},
+		{nbTrees: 10, rows: []int{0, 50, 0}, want: []int{0, 10, 0}},
+		{nbTrees: 3, rows: []int{10, 70, 20}, want: []int{0, 2, 1}},
+	}
+	for _, tt := range tests {
+
+		infos := make([]Info, len(tt.rows))
+		for k, r := range tt.rows {
+			infos[k].Rows = r
+		}
+		if got := shareTrees(tt.nbTrees, infos); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("shareTrees(%v, %v) = %v, want %v", tt.nbTrees, tt.rows, got, tt.want)
+		}
+
+	}
+}
+
+func Test_deriveSeed(t *testing.T) {
+	seen := make(map[int64]bool)
+	for worker := 0; worker < 10; worker++ {
+		for batch := 0; batch < 10; batch++ {
+			s := deriveSeed(42, worker, batch)
+			if s == 0 || seen[s] {
+				t.Errorf("deriveSeed(42, %d, %d) = %v, want a new non zero seed", worker, batch, s)
+			}
+			seen[s] = true
+		}
+	}
+	if deriveSeed(1, 0, 0) == deriveSeed(2, 0, 0) {
+		t.Errorf("deriveSeed() does not depend on the seed")
+	}
+}
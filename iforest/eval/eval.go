@@ -0,0 +1,315 @@
+// Package eval computes evaluation metrics of anomaly scores against ground
+// truth labels.
+//
+// Scores follow the convention of the iforest package: the lower the score,
+// the more anomalous the vector, and a vector is labelled as an anomaly when
+// its score is lower than the anomaly bound. Labels are 1 for anomalies and 0
+// for normal vectors. Vectors with equal scores cannot be ranked against each
+// other, so they always enter curves together.
+package eval
+
+import (
+	"errors"
+	"math"
+	"sort"
+)
+
+// ConfusionMatrix counts the vectors by predicted and actual label.
+type ConfusionMatrix struct {
+	TP int
+	FP int
+	TN int
+	FN int
+}
+
+// Precision returns the ratio of actual anomalies among the detected ones, 0
+// when nothing is detected.
+func (c ConfusionMatrix) Precision() float64 {
+	if c.TP+c.FP == 0 {
+		return 0
+	}
+	return float64(c.TP) / float64(c.TP+c.FP)
+}
+
+// Recall returns the ratio of detected anomalies among the actual ones, 0 when
+// there are no anomalies.
+func (c ConfusionMatrix) Recall() float64 {
+	if c.TP+c.FN == 0 {
+		return 0
+	}
+	return float64(c.TP) / float64(c.TP+c.FN)
+}
+
+// FPR returns the ratio of normal vectors detected as anomalies, 0 when there
+// are no normal vectors.
+func (c ConfusionMatrix) FPR() float64 {
+	if c.FP+c.TN == 0 {
+		return 0
+	}
+	return float64(c.FP) / float64(c.FP+c.TN)
+}
+
+// F1 returns the harmonic mean of precision and recall.
+func (c ConfusionMatrix) F1() float64 {
+	p, r := c.Precision(), c.Recall()
+	if p+r == 0 {
+		return 0
+	}
+	return 2 * p * r / (p + r)
+}
+
+// Confusion labels the scores with given anomaly bound, as the forest does, and
+// compares them to the ground truth.
+func Confusion(scores []float64, labels []int, bound float64) (ConfusionMatrix, error) {
+	if err := check(scores, labels); err != nil {
+		return ConfusionMatrix{}, err
+	}
+	var c ConfusionMatrix
+	for i, s := range scores {
+		switch {
+		case s < bound && labels[i] == 1:
+			c.TP++
+		case s < bound:
+			c.FP++
+		case labels[i] == 1:
+			c.FN++
+		default:
+			c.TN++
+		}
+	}
+	return c, nil
+}
+
+// ROCPoint is a point of the ROC curve, obtained by labelling as anomalies the
+// vectors with a score lower than Bound.
+type ROCPoint struct {
+	Bound float64
+	FPR   float64
+	TPR   float64
+}
+
+// ROC computes the receiver operating characteristic curve. The curve starts at
+// (0, 0), where nothing is detected, and gets one point for each distinct
+// score, up to (1, 1).
+func ROC(scores []float64, labels []int) ([]ROCPoint, error) {
+	steps, positives, err := sweep(scores, labels)
+	if err != nil {
+		return nil, err
+	}
+	negatives := len(scores) - positives
+	if positives == 0 || negatives == 0 {
+		return nil, errors.New("ROC curve needs both anomalies and normal vectors")
+	}
+
+	points := make([]ROCPoint, 0, len(steps))
+	for _, st := range steps {
+		points = append(points, ROCPoint{
+			Bound: st.bound,
+			FPR:   float64(st.fp) / float64(negatives),
+			TPR:   float64(st.tp) / float64(positives),
+		})
+	}
+	return points, nil
+}
+
+// AUC computes the area under the ROC curve with the trapezoidal rule. Tied
+// scores of an anomaly and a normal vector count as half ranked correctly.
+func AUC(scores []float64, labels []int) (float64, error) {
+	points, err := ROC(scores, labels)
+	if err != nil {
+		return 0, err
+	}
+	var area float64
+	for i := 1; i < len(points); i++ {
+		area += (points[i].FPR - points[i-1].FPR) * (points[i].TPR + points[i-1].TPR) / 2
+	}
+	return area, nil
+}
+
+// PRPoint is a point of the precision-recall curve, obtained by labelling as
+// anomalies the vectors with a score lower than Bound.
+type PRPoint struct {
+	Bound     float64
+	Recall    float64
+	Precision float64
+}
+
+// PRCurve computes the precision-recall curve, with one point for each distinct
+// score, from the most anomalous one to the least anomalous one.
+func PRCurve(scores []float64, labels []int) ([]PRPoint, error) {
+	steps, positives, err := sweep(scores, labels)
+	if err != nil {
+		return nil, err
+	}
+	if positives == 0 {
+		return nil, errors.New("precision-recall curve needs anomalies")
+	}
+
+	points := make([]PRPoint, 0, len(steps)-1)
+	for _, st := range steps[1:] {
+		points = append(points, PRPoint{
+			Bound:     st.bound,
+			Recall:    float64(st.tp) / float64(positives),
+			Precision: float64(st.tp) / float64(st.tp+st.fp),
+		})
+	}
+	return points, nil
+}
+
+// AveragePrecision summarizes the precision-recall curve as the mean of the
+// precisions weighted by the increase of recall at each point. It is the
+// area under the curve without interpolation.
+func AveragePrecision(scores []float64, labels []int) (float64, error) {
+	points, err := PRCurve(scores, labels)
+	if err != nil {
+		return 0, err
+	}
+	var ap, recall float64
+	for _, p := range points {
+		ap += (p.Recall - recall) * p.Precision
+		recall = p.Recall
+	}
+	return ap, nil
+}
+
+// PrecisionAtK computes the ratio of anomalies among the k most anomalous
+// vectors. When tied scores straddle the k-th rank, the tied vectors count
+// proportionally to the number of them within the k first ranks.
+func PrecisionAtK(scores []float64, labels []int, k int) (float64, error) {
+	if err := check(scores, labels); err != nil {
+		return 0, err
+	}
+	if k <= 0 || k > len(scores) {
+		return 0, errors.New("k must be between 1 and the number of scores")
+	}
+
+	ids := sortedIds(scores)
+	var hits float64
+	for start := 0; start < k; {
+		stop := start + 1
+		var group float64
+		for stop < len(ids) && scores[ids[stop]] == scores[ids[start]] {
+			stop++
+		}
+		for _, id := range ids[start:stop] {
+			group += float64(labels[id])
+		}
+		taken := math.Min(float64(stop), float64(k)) - float64(start)
+		hits += group * taken / float64(stop-start)
+		start = stop
+	}
+	return hits / float64(k), nil
+}
+
+// step is the state of a sweep over the sorted scores.
+type step struct {
+	bound float64
+	tp    int
+	fp    int
+}
+
+// sweep labels more and more vectors as anomalies, one group of equal scores at
+// a time. The first step detects nothing.
+func sweep(scores []float64, labels []int) ([]step, int, error) {
+	if err := check(scores, labels); err != nil {
+		return nil, 0, err
+	}
+	var positives int
+	for _, l := range labels {
+		positives += l
+	}
+
+	ids := sortedIds(scores)
+	steps := []step{{bound: scores[ids[0]]}}
+	var tp, fp int
+	for i, id := range ids {
+		if labels[id] == 1 {
+			tp++
+		} else {
+			fp++
+		}
+		if i+1 < len(ids) && scores[ids[i+1]] == scores[id] {
+			continue
+		}
+		steps = append(steps, step{bound: math.Nextafter(scores[id], math.Inf(1)), tp: tp, fp: fp})
+	}
+	return steps, positives, nil
+}
+
+func check(scores []float64, labels []int) error {
+	if len(scores) != len(labels) {
+		return errors.New("scores and labels lengths differ")
+	}
+	if len(scores) == 0 {
+		return errors.New("no scores")
+	}
+	for _, l := range labels {
+		if l != 0 && l != 1 {
+			return errors.New("labels must be 0 or 1")
+		}
+	}
+	return nil
+}
+
+// sortedIds returns the indices of the scores sorted from the most anomalous
+// to the least anomalous one.
+func sortedIds(scores []float64) []int {
+	ids := make([]int, len(scores))
+	for i := range ids {
+		ids[i] = i
+	}
+	sort.SliceStable(ids, func(i, j int) bool {
+		return scores[ids[i]] < scores[ids[j]]
+	})
+	return ids
+}
+
+// Spearman computes the Spearman's rank correlation coefficient between two
+// series of scores, tied scores getting the mean of their ranks. It measures how
+// similarly two models rank the same vectors.
+func Spearman(a, b []float64) (float64, error) {
+	if len(a) != len(b) {
+		return 0, errors.New("series lengths differ")
+	}
+	if len(a) < 2 {
+		return 0, errors.New("not enough scores")
+	}
+	ra, rb := ranks(a), ranks(b)
+
+	var ma, mb float64
+	for i := range ra {
+		ma += ra[i]
+		mb += rb[i]
+	}
+	ma /= float64(len(ra))
+	mb /= float64(len(rb))
+
+	var cov, va, vb float64
+	for i := range ra {
+		cov += (ra[i] - ma) * (rb[i] - mb)
+		va += (ra[i] - ma) * (ra[i] - ma)
+		vb += (rb[i] - mb) * (rb[i] - mb)
+	}
+	if va == 0 || vb == 0 {
+		return 0, errors.New("correlation is undefined for constant scores")
+	}
+	return cov / math.Sqrt(va*vb), nil
+}
+
+// ranks returns the rank of each score, from 1 for the lowest one.
+func ranks(scores []float64) []float64 {
+	ids := sortedIds(scores)
+	r := make([]float64, len(scores))
+	for start := 0; start < len(ids); {
+		stop := start + 1
+		for stop < len(ids) && scores[ids[stop]] == scores[ids[start]] {
+			stop++
+		}
+		rank := float64(start+stop+1) / 2
+		for _, id := range ids[start:stop] {
+			r[id] = rank
+		}
+		start = stop
+	}
+	return r
+}
@@ -0,0 +1,212 @@
+package eval
+
+import (
+	"math"
+	"reflect"
+	"testing"
+)
+
+var (
+	testScores = []float64{-0.1, 0.1, -0.3, 0, -0.2}
+	testLabels = []int{1, 0, 1, 0, 0}
+)
+
+func almostEqual(a, b float64) bool {
+	return math.Abs(a-b) < 1e-12
+}
+
+func TestConfusion(t *testing.T) {
+	tests := []struct {
+		scores  []float64
+		labels  []int
+		bound   float64
+		want    ConfusionMatrix
+		wantErr bool
+	}{
+		{scores: testScores, labels: testLabels, bound: -0.15, want: ConfusionMatrix{TP: 1, FP: 1, TN: 2, FN: 1}},
+		{scores: testScores, labels: testLabels, bound: -0.3, want: ConfusionMatrix{TP: 0, FP: 0, TN: 3, FN: 2}},
+		{scores: testScores, labels: testLabels, bound: 1, want: ConfusionMatrix{TP: 2, FP: 3, TN: 0, FN: 0}},
+		{scores: testScores, labels: []int{1}, wantErr: true},
+		{scores: testScores, labels: []int{1, 0, 2, 0, 0}, wantErr: true},
+		{scores: nil, labels: nil, wantErr: true},
+	}
+	for _, tt := range tests {
+
+		got, err := Confusion(tt.scores, tt.labels, tt.bound)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("Confusion() error = %v, wantErr %v", err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("Confusion() = %+v, want %+v", got, tt.want)
+		}
+
+	}
+}
+
+func TestConfusionMatrix_Metrics(t *testing.T) {
+	c := ConfusionMatrix{TP: 1, FP: 1, TN: 2, FN: 1}
+	if got := c.Precision(); got != 0.5 {
+		t.Errorf("ConfusionMatrix.Precision() = %v, want 0.5", got)
+	}
+	if got := c.Recall(); got != 0.5 {
+		t.Errorf("ConfusionMatrix.Recall() = %v, want 0.5", got)
+	}
+	if got := c.FPR(); !almostEqual(got, 1.0/3) {
+		t.Errorf("ConfusionMatrix.FPR() = %v, want 1/3", got)
+	}
+	if got := c.F1(); got != 0.5 {
+		t.Errorf("ConfusionMatrix.F1() = %v, want 0.5", got)
+	}
+	if got := (ConfusionMatrix{TN: 3}).F1(); got != 0 {
+		t.Errorf("ConfusionMatrix.F1() = %v, want 0", got)
+	}
+}
+
+func TestROC(t *testing.T) {
+	points, err := ROC(testScores, testLabels)
+	if err != nil {
+		t.Fatalf("ROC() error = %v", err)
+	}
+	wantFPR := []float64{0, 0, 1.0 / 3, 1.0 / 3, 2.0 / 3, 1}
+	wantTPR := []float64{0, 0.5, 0.5, 1, 1, 1}
+	if len(points) != len(wantFPR) {
+		t.Fatalf("ROC() = %v, want %v points", points, len(wantFPR))
+	}
+	for i, p := range points {
+		if !almostEqual(p.FPR, wantFPR[i]) || !almostEqual(p.TPR, wantTPR[i]) {
+			t.Errorf("ROC() point %d = %+v, want FPR %v, TPR %v", i, p, wantFPR[i], wantTPR[i])
+		}
+		c, _ := Confusion(testScores, testLabels, p.Bound)
+		if !almostEqual(c.FPR(), p.FPR) || !almostEqual(c.Recall(), p.TPR) {
+			t.Errorf("ROC() point %d = %+v, confusion at its bound is %+v", i, p, c)
+		}
+	}
+
+	if _, err := ROC([]float64{1, 2}, []int{0, 0}); err == nil {
+		t.Errorf("ROC() without anomalies should fail")
+	}
+}
+
+func TestAUC(t *testing.T) {
+	tests := []struct {
+		scores []float64
+		labels []int
+		want   float64
+	}{
+		{scores: testScores, labels: testLabels, want: 5.0 / 6},
+		{scores: []float64{0, 0}, labels: []int{1, 0}, want: 0.5},
+		{scores: []float64{-1, 0, 0, 1}, labels: []int{1, 1, 0, 0}, want: 7.0 / 8},
+		{scores: []float64{1, 0}, labels: []int{1, 0}, want: 0},
+	}
+	for _, tt := range tests {
+
+		got, err := AUC(tt.scores, tt.labels)
+		if err != nil {
+			t.Fatalf("AUC() error = %v", err)
+		}
+		if !almostEqual(got, tt.want) {
+			t.Errorf("AUC(%v, %v) = %v, want %v", tt.scores, tt.labels, got, tt.want)
+		}
+
+	}
+}
+
+func TestPRCurve(t *testing.T) {
+	points, err := PRCurve(testScores, testLabels)
+	if err != nil {
+		t.Fatalf("PRCurve() error = %v", err)
+	}
+	want := []PRPoint{{Recall: 0.5, Precision: 1}, {Recall: 0.5, Precision: 0.5}, {Recall: 1, Precision: 2.0 / 3}, {Recall: 1, Precision: 0.5}, {Recall: 1, Precision: 0.4}}
+	got := make([]PRPoint, len(points))
+	for i, p := range points {
+		got[i] = PRPoint{Recall: p.Recall, Precision: p.Precision}
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("PRCurve() = %v, want %v", got, want)
+	}
+
+	if _, err := PRCurve([]float64{1, 2}, []int{0, 0}); err == nil {
+		t.Errorf("PRCurve() without anomalies should fail")
+	}
+}
+
+func TestAveragePrecision(t *testing.T) {
+	tests := []struct {
+		scores []float64
+		labels []int
+		want   float64
+	}{
+		{scores: testScores, labels: testLabels, want: 5.0 / 6},
+		{scores: []float64{0, 0}, labels: []int{1, 0}, want: 0.5},
+		{scores: []float64{-1, 0, 1}, labels: []int{1, 1, 0}, want: 1},
+	}
+	for _, tt := range tests {
+
+		got, err := AveragePrecision(tt.scores, tt.labels)
+		if err != nil {
+			t.Fatalf("AveragePrecision() error = %v", err)
+		}
+		if !almostEqual(got, tt.want) {
+			t.Errorf("AveragePrecision(%v, %v) = %v, want %v", tt.scores, tt.labels, got, tt.want)
+		}
+
+	}
+}
+
+func TestPrecisionAtK(t *testing.T) {
+	tests := []struct {
+		scores  []float64
+		labels  []int
+		k       int
+		want    float64
+		wantErr bool
+	}{
+		{scores: testScores, labels: testLabels, k: 1, want: 1},
+		{scores: testScores, labels: testLabels, k: 2, want: 0.5},
+		{scores: testScores, labels: testLabels, k: 3, want: 2.0 / 3},
+		{scores: []float64{-1, 0, 0, 0}, labels: []int{1, 1, 0, 0}, k: 2, want: 2.0 / 3},
+		{scores: testScores, labels: testLabels, k: 0, wantErr: true},
+		{scores: testScores, labels: testLabels, k: 6, wantErr: true},
+	}
+	for _, tt := range tests {
+
+		got, err := PrecisionAtK(tt.scores, tt.labels, tt.k)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("PrecisionAtK() error = %v, wantErr %v", err, tt.wantErr)
+			continue
+		}
+		if !almostEqual(got, tt.want) {
+			t.Errorf("PrecisionAtK(k=%v) = %v, want %v", tt.k, got, tt.want)
+		}
+
+	}
+}
+
+func TestSpearman(t *testing.T) {
+	tests := []struct {
+		a, b    []float64
+		want    float64
+		wantErr bool
+	}{
+		{a: []float64{1, 2, 3, 4}, b: []float64{10, 20, 30, 40}, want: 1},
+		{a: []float64{1, 2, 3, 4}, b: []float64{4, 3, 2, 1}, want: -1},
+		{a: []float64{1, 2, 3}, b: []float64{1, 3, 2}, want: 0.5},
+		{a: []float64{1, 1, 2}, b: []float64{1, 2, 3}, want: math.Sqrt(3) / 2},
+		{a: []float64{1, 2}, b: []float64{1}, wantErr: true},
+		{a: []float64{1}, b: []float64{1}, wantErr: true},
+		{a: []float64{1, 1}, b: []float64{1, 2}, wantErr: true},
+	}
+	for _, tt := range tests {
+
+		got, err := Spearman(tt.a, tt.b)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("Spearman() error = %v, wantErr %v", err, tt.wantErr)
+			continue
+		}
+		if !almostEqual(got, tt.want) {
+			t.Errorf("Spearman(%v, %v) = %v, want %v", tt.a, tt.b, got, tt.want)
+		}
+
+	}
+}
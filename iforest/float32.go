@@ -0,0 +1,292 @@
+package iforest
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"os"
+)
+
+// RowSlices32 adapts a slice of float32 rows to the Matrix interface. All rows
+// are expected to have the same length.
+type RowSlices32 [][]float32
+
+// Dims returns the number of rows and columns of the matrix.
+func (m RowSlices32) Dims() (int, int) {
+	if len(m) == 0 {
+		return 0, 0
+	}
+	return len(m), len(m[0])
+}
+
+// At returns the value of the element at row i and column j.
+func (m RowSlices32) At(i, j int) float64 {
+	return float64(m[i][j])
+}
+
+// Forest32 is a compact version of Forest for memory constrained deployments.
+// Split values and adjustments are stored as float32 and trees are kept in flat
+// arrays, which takes less than half of the memory and of the serialized size
+// of a Forest.
+//
+// Split values are rounded up to the nearest float32, so float32 vectors take
+// exactly the same path in a Forest32 as in the Forest it was built from. Path
+// adjustments c(Size) and CSubSampl are rounded to nearest, so scores differ
+// from the float64 ones by a relative error of the order of 1e-7. Scoring
+// float64 vectors which are not representable as float32 may take a different
+// path when the value lies between the split and its rounding.
+type Forest32 struct {
+	Trees           []Tree32
+	NbTrees         int
+	SubsamplingSize int
+	HeightLimit     int
+	CSubSampl       float32
+	AnomalyBound    float32
+	AnomalyRatio    float32
+	Trained         bool
+	Tested          bool
+
+	// Aggregation, TrimRatio and TreeWeights combine the path lengths of the
+	// trees, as in Forest.
+	Aggregation Aggregation `json:",omitempty"`
+	TrimRatio   float64     `json:",omitempty"`
+	TreeWeights []float64   `json:",omitempty"`
+
+	// Seed makes the training reproducible when it is not 0, as Forest.Seed.
+	Seed int64 `json:",omitempty"`
+
+	// TunedBound is the anomaly bound kept by Test, as Forest.TunedBound.
+	// Without it, Test chooses the bound from AnomalyRatio: the Thresholder
+	// of a converted Forest is not kept.
+	TunedBound *float64 `json:",omitempty"`
+}
+
+// Tree32 is an iTree stored in flat arrays. Node 0 is the root, the children
+// of internal node k are Left[k] and Right[k] while external nodes have no
+// children (Left[k] == 0).
+type Tree32 struct {
+	Left      []int32
+	Right     []int32
+	Attribute []int32
+	Size      []int32
+	Split     []float32
+	C         []float32
+	// Scale multiplies the path lengths of the tree, as Tree.Scale.
+	Scale float32
+}
+
+// NewForest32 initializes Forest32 structure.
+func NewForest32(nbTrees, subsamplingSize int, anomalyRatio float32) *Forest32 {
+	f := NewForest(nbTrees, subsamplingSize, float64(anomalyRatio))
+	return &Forest32{
+		Trees:           make([]Tree32, nbTrees),
+		NbTrees:         nbTrees,
+		SubsamplingSize: subsamplingSize,
+		HeightLimit:     f.HeightLimit,
+		CSubSampl:       float32(f.CSubSampl),
+		AnomalyRatio:    anomalyRatio,
+	}
+}
+
+// Float32 converts the forest to its compact float32 version. The trained
+// trees, the anomaly bound, the tuned bound, the aggregation and the state of
+// the forest are kept.
+func (f *Forest) Float32() *Forest32 {
+	trees := make([]Tree32, len(f.Trees))
+	for i := range f.Trees {
+		trees[i] = compactTree(f.Trees[i])
+	}
+	var tuned *float64
+	if f.TunedBound != nil {
+		bound := *f.TunedBound
+		tuned = &bound
+	}
+	return &Forest32{
+		Trees:           trees,
+		NbTrees:         f.NbTrees,
+		SubsamplingSize: f.SubsamplingSize,
+		HeightLimit:     f.HeightLimit,
+		CSubSampl:       float32(f.CSubSampl),
+		AnomalyBound:    float32(f.AnomalyBound),
+		AnomalyRatio:    float32(f.AnomalyRatio),
+		Trained:         f.Trained,
+		Tested:          f.Tested,
+		Aggregation:     f.Aggregation,
+		TrimRatio:       f.TrimRatio,
+		TreeWeights:     append([]float64(nil), f.TreeWeights...),
+		Seed:            f.Seed,
+		TunedBound:      tuned,
+	}
+}
+
+// Train creates the collection of trees in the forest. Trees are built with
+// float64 split values and compacted once built.
+func (f *Forest32) Train(X [][]float32) {
+	forest := NewForest(f.NbTrees, f.SubsamplingSize, float64(f.AnomalyRatio))
+	forest.Seed = f.Seed
+	forest.TrainMatrix(RowSlices32(X))
+
+	f.Trees = make([]Tree32, len(forest.Trees))
+	for i := range forest.Trees {
+		f.Trees[i] = compactTree(forest.Trees[i])
+	}
+	f.Trained = true
+}
+
+// Test computes anomaly scores for the dataset and chooses anomaly score that
+// will be the bound for detecting anomalies, unless a TunedBound is set. Unlike
+// Forest, scores and labels of the dataset are not kept in the model.
+func (f *Forest32) Test(X [][]float32) error {
+
+	if !f.Trained {
+		return errors.New("cannot start testing phase - model has not been trained yet")
+	}
+	if err := f.checkAggregation(); err != nil {
+		return err
+	}
+
+	scores := make([]float64, len(X))
+	for i := range X {
+		scores[i] = f.score(X[i])
+	}
+	var t Thresholder = Contamination(f.AnomalyRatio)
+	if f.TunedBound != nil {
+		t = FixedScore(*f.TunedBound)
+	}
+	bound, err := t.Threshold(scores)
+	if err != nil {
+		return err
+	}
+	f.AnomalyBound = float32(bound)
+	f.Tested = true
+
+	return nil
+}
+
+// Predict computes anomaly scores for given dataset and classifies each vector
+// as 'normal' or 'anomaly'.
+func (f *Forest32) Predict(X [][]float32) ([]int, []float32, error) {
+
+	if !f.Trained {
+		return nil, nil, errors.New("cannot predict - model has not been trained yet")
+	}
+	if !f.Tested {
+		return nil, nil, errors.New("cannot predict - model has not been tested yet")
+	}
+	if err := f.checkAggregation(); err != nil {
+		return nil, nil, err
+	}
+
+	labels := make([]int, len(X))
+	scores := make([]float32, len(X))
+	for i := range X {
+		scores[i] = float32(f.score(X[i]))
+		if scores[i] < f.AnomalyBound {
+			labels[i] = 1
+		}
+	}
+
+	return labels, scores, nil
+}
+
+// checkAggregation verifies that the parameters of the aggregation are valid.
+func (f *Forest32) checkAggregation() error {
+	return checkAggregation(f.Aggregation, f.TrimRatio, f.TreeWeights, len(f.Trees))
+}
+
+// score computes the anomaly score of given vector. Path lengths are combined
+// in float64 to avoid accumulating rounding errors over the trees.
+func (f *Forest32) score(V []float32) float64 {
+	paths := make([]float64, len(f.Trees))
+	for j := range f.Trees {
+		paths[j] = f.Trees[j].PathLength(V)
+		if f.Trees[j].Scale != 0 {
+			paths[j] *= float64(f.Trees[j].Scale)
+		}
+	}
+	averagePath := aggregate(f.Aggregation, f.TrimRatio, f.TreeWeights, paths)
+	return 0.5 - math.Pow(2, (-averagePath/float64(f.CSubSampl)))
+}
+
+// PathLength computes the length of the path for given data vector, with the
+// same adjustment as the PathLength function.
+func (t *Tree32) PathLength(V []float32) float64 {
+	var pathLength int
+	k := int32(0)
+	for {
+		pathLength++
+		if V[t.Attribute[k]] < t.Split[k] {
+			k = t.Left[k]
+		} else {
+			k = t.Right[k]
+		}
+
+		if t.Size[k] <= 1 {
+			return float64(pathLength)
+		}
+		if t.Left[k] == 0 {
+			return float64(pathLength) + float64(t.C[k])
+		}
+	}
+}
+
+// compactTree flattens given tree in depth first order.
+func compactTree(t Tree) Tree32 {
+	var t32 Tree32
+	var add func(n *Node) int32
+	add = func(n *Node) int32 {
+		k := int32(len(t32.Left))
+		t32.Left = append(t32.Left, 0)
+		t32.Right = append(t32.Right, 0)
+		t32.Attribute = append(t32.Attribute, int32(n.Attribute))
+		t32.Size = append(t32.Size, int32(n.Size))
+		t32.Split = append(t32.Split, RoundUp32(n.Split))
+		t32.C = append(t32.C, float32(n.C))
+		if !n.External {
+			left := add(n.Left)
+			right := add(n.Right)
+			t32.Left[k], t32.Right[k] = left, right
+		}
+		return k
+	}
+	if t.Root != nil {
+		add(t.Root)
+	}
+	t32.Scale = float32(t.Scale)
+	return t32
+}
+
+// RoundUp32 returns the smallest float32 which is not lower than x. For any
+// float32 v, v < x is then equivalent to v < RoundUp32(x). Forest32 rounds its
+// split values with it.
+func RoundUp32(x float64) float32 {
+	r := float32(x)
+	if float64(r) < x {
+		r = math.Nextafter32(r, float32(math.Inf(1)))
+	}
+	return r
+}
+
+// Save saves model in the file
+func (f *Forest32) Save(path string) error {
+
+	file, err := os.Create(path)
+	if err == nil {
+		encoder := json.NewEncoder(file)
+		err = encoder.Encode(f)
+	}
+	file.Close()
+	return err
+}
+
+// Load loads from the file
+func (f *Forest32) Load(path string) error {
+	file, err := os.Open(path)
+	if err == nil {
+		decoder := json.NewDecoder(file)
+		err = decoder.Decode(&f)
+	}
+	file.Close()
+
+	return err
+}
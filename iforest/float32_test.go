@@ -0,0 +1,219 @@
+package iforest
+
+import (
+	"bytes"
+	"encoding/json"
+	"math"
+	"math/rand"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func randomData32(n, cols int) ([][]float32, [][]float64) {
+	X32 := make([][]float32, n)
+	X64 := make([][]float64, n)
+	for i := range X32 {
+		X32[i] = make([]float32, cols)
+		X64[i] = make([]float64, cols)
+		for j := range X32[i] {
+			X32[i][j] = float32(rand.NormFloat64())
+			if i%50 == 0 {
+				X32[i][j] += 8
+			}
+			X64[i][j] = float64(X32[i][j])
+		}
+	}
+	return X32, X64
+}
+
+func Test_RoundUp32(t *testing.T) {
+	tests := []float64{0, 1, -1, 0.1, -0.1, 1.0000001, math.Pi, -math.Pi, 1e30, -1e-30}
+	for _, x := range tests {
+
+		got := RoundUp32(x)
+		if float64(got) < x {
+			t.Errorf("RoundUp32(%v) = %v, lower than x", x, got)
+		}
+		if below := math.Nextafter32(got, float32(math.Inf(-1))); float64(below) >= x {
+			t.Errorf("RoundUp32(%v) = %v, %v is not lower than x", x, got, below)
+		}
+
+	}
+}
+
+func TestForest_Float32(t *testing.T) {
+	X32, X64 := randomData32(500, 4)
+
+	f := NewForest(50, 64, 0.02)
+	f.Train(X64)
+	f.Test(X64)
+	f32 := f.Float32()
+
+	for i := range X32 {
+		for j := range f.Trees {
+			want := PathLength(X64[i], 0, f.Trees[j].Root)
+			got := f32.Trees[j].PathLength(X32[i])
+			if math.Abs(got-want) > 1e-5 {
+				t.Fatalf("Tree32.PathLength() = %v, want %v", got, want)
+			}
+		}
+	}
+
+	_, wantScores, _ := f.Predict(X64)
+	_, scores, err := f32.Predict(X32)
+	if err != nil {
+		t.Fatalf("Forest32.Predict() error = %v", err)
+	}
+	for i := range scores {
+		if math.Abs(float64(scores[i])-wantScores[i]) > 1e-6 {
+			t.Errorf("Forest32.Predict() score = %v, want %v", scores[i], wantScores[i])
+		}
+	}
+}
+
+func TestForest32_Train(t *testing.T) {
+	X32, _ := randomData32(300, 3)
+
+	tests := []struct {
+		f          *Forest32
+		trainFirst bool
+		testFirst  bool
+		wantErr    bool
+	}{
+		{f: NewForest32(10, 32, 0.1), trainFirst: false, testFirst: false, wantErr: true},
+		{f: NewForest32(10, 32, 0.1), trainFirst: true, testFirst: false, wantErr: true},
+		{f: NewForest32(10, 32, 0.1), trainFirst: true, testFirst: true, wantErr: false},
+	}
+	for _, tt := range tests {
+		if tt.trainFirst {
+			tt.f.Train(X32)
+		}
+		if tt.testFirst {
+			if err := tt.f.Test(X32); err != nil {
+				t.Fatalf("Forest32.Test() error = %v", err)
+			}
+		}
+		labels, _, err := tt.f.Predict(X32)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("Forest32.Predict() error = %v, wantErr %v", err, tt.wantErr)
+		}
+		if err == nil && len(labels) != len(X32) {
+			t.Errorf("Forest32.Predict() returned %v labels, want %v", len(labels), len(X32))
+		}
+	}
+}
+
+func TestForest_Float32Aggregation(t *testing.T) {
+	X32, X64 := randomData32(300, 3)
+
+	f := NewForest(30, 64, 0.05)
+	f.Seed = 1
+	f.Train(X64)
+	weights := make([]float64, len(f.Trees))
+	for j := range weights {
+		weights[j] = float64(j + 1)
+	}
+	tests := []struct {
+		agg     Aggregation
+		weights []float64
+	}{
+		{agg: MedianAggregation},
+		{agg: TrimmedMeanAggregation},
+		{agg: WeightedAggregation, weights: weights},
+	}
+	for _, tt := range tests {
+
+		f.Aggregation, f.TrimRatio, f.TreeWeights = tt.agg, 0.1, tt.weights
+		f.Test(X64)
+		_, want, _ := f.Predict(X64)
+		_, got, err := f.Float32().Predict(X32)
+		if err != nil {
+			t.Errorf("Forest32.Predict() with %q error = %v", tt.agg, err)
+			continue
+		}
+		for i := range want {
+			if math.Abs(float64(got[i])-want[i]) > 1e-6 {
+				t.Errorf("Forest32.Predict() with %q = %v, want %v", tt.agg, got[i], want[i])
+				break
+			}
+		}
+
+	}
+
+	f32 := f.Float32()
+	f32.TreeWeights = f32.TreeWeights[1:]
+	if _, _, err := f32.Predict(X32); err == nil {
+		t.Errorf("Forest32.Predict() with missing tree weights error = nil, want an error")
+	}
+}
+
+func TestForest_Float32TunedBound(t *testing.T) {
+	X32, X64 := randomData32(200, 3)
+
+	f := NewForest(30, 64, 0.05)
+	f.Seed = 2
+	f.Train(X64)
+	f.Test(X64)
+	tuned := 0.01
+	f.TunedBound = &tuned
+
+	f32 := f.Float32()
+	tuned = 0.02
+	if err := f32.Test(X32); err != nil {
+		t.Fatalf("Forest32.Test() error = %v", err)
+	}
+	if f32.AnomalyBound != float32(0.01) {
+		t.Errorf("Forest32.Test() bound = %v, want the tuned bound 0.01", f32.AnomalyBound)
+	}
+}
+
+func TestForest32_Seed(t *testing.T) {
+	X32, _ := randomData32(200, 3)
+
+	forests := make([]*Forest32, 2)
+	for i := range forests {
+		forests[i] = NewForest32(10, 32, 0.1)
+		forests[i].Seed = 42
+		forests[i].Train(X32)
+	}
+	if !reflect.DeepEqual(forests[0].Trees, forests[1].Trees) {
+		t.Errorf("Forest32.Train() with the same seed builds different trees")
+	}
+}
+
+func TestForest32_SaveLoad(t *testing.T) {
+	X32, X64 := randomData32(300, 3)
+
+	f := NewForest(20, 64, 0.05)
+	f.Train(X64)
+	f.Test(X64)
+	f32 := f.Float32()
+
+	path := filepath.Join(t.TempDir(), "model32")
+	if err := f32.Save(path); err != nil {
+		t.Fatalf("Forest32.Save() error = %v", err)
+	}
+	loaded := &Forest32{}
+	if err := loaded.Load(path); err != nil {
+		t.Fatalf("Forest32.Load() error = %v", err)
+	}
+	_, want, _ := f32.Predict(X32)
+	_, got, _ := loaded.Predict(X32)
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("loaded Forest32.Predict() = %v, want %v", got[i], want[i])
+		}
+	}
+
+	var buf64, buf32 bytes.Buffer
+	json.NewEncoder(&buf64).Encode(&Forest{Trees: f.Trees})
+	json.NewEncoder(&buf32).Encode(&Forest32{Trees: f32.Trees})
+	if buf32.Len()*2 > buf64.Len() {
+		t.Errorf("serialized Forest32 takes %v bytes, Forest %v", buf32.Len(), buf64.Len())
+	}
+
+	if err := loaded.Load(filepath.Join(t.TempDir(), "missing")); err == nil {
+		t.Errorf("Forest32.Load() of missing file should fail")
+	}
+}
@@ -0,0 +1,159 @@
+package iforest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Holder holds a model saved in a file and swaps it atomically when the file
+// is reloaded. Calls made with the previous model, like Predict, finish on it
+// while new calls use the reloaded one.
+type Holder struct {
+	path     string
+	features int
+	forest   atomic.Pointer[Forest]
+
+	mu      sync.Mutex
+	modTime time.Time
+	size    int64
+}
+
+// NewHolder loads the model saved in given file. The model must be trained
+// and tested.
+func NewHolder(path string) (*Holder, error) {
+	h := &Holder{path: path}
+	f, info, err := h.load()
+	if err != nil {
+		return nil, err
+	}
+	h.forest.Store(f)
+	h.features = nbFeatures(f)
+	h.modTime, h.size = info.ModTime(), info.Size()
+	return h, nil
+}
+
+// Path returns the path of the model file.
+func (h *Holder) Path() string {
+	return h.path
+}
+
+// Features returns the number of features expected by the model, which does
+// not change when it is reloaded.
+func (h *Holder) Features() int {
+	return h.features
+}
+
+// Forest returns the current model. It must not be modified.
+func (h *Holder) Forest() *Forest {
+	return h.forest.Load()
+}
+
+// Predict does the same as Forest.Predict with the current model.
+func (h *Holder) Predict(X [][]float64) ([]int, []float64, error) {
+	return h.Forest().Predict(X)
+}
+
+// PredictParallel does the same as Forest.PredictParallel with the current
+// model.
+func (h *Holder) PredictParallel(X [][]float64, routinesNumber int) ([]int, []float64, error) {
+	return h.Forest().PredictParallel(X, routinesNumber)
+}
+
+// Reload loads the model file again and swaps it in. The current model is
+// kept when the file cannot be loaded or when the new model does not have the
+// same number of features.
+func (h *Holder) Reload() error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.reload()
+}
+
+func (h *Holder) reload() error {
+	f, info, err := h.load()
+	if err != nil {
+		return err
+	}
+	if got := nbFeatures(f); got != h.features {
+		return fmt.Errorf("cannot reload - model has %d features, want %d", got, h.features)
+	}
+	h.forest.Store(f)
+	h.modTime, h.size = info.ModTime(), info.Size()
+	return nil
+}
+
+// Watch checks the model file at each interval and reloads it when its
+// modification time or size changed, until the context is done. Errors of
+// the reloads are passed to onError, which may be nil.
+func (h *Holder) Watch(ctx context.Context, interval time.Duration, onError func(error)) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		h.mu.Lock()
+		info, err := os.Stat(h.path)
+		if err == nil && (!info.ModTime().Equal(h.modTime) || info.Size() != h.size) {
+			err = h.reload()
+			if err != nil {
+				// the same file is not reloaded until it changes again
+				h.modTime, h.size = info.ModTime(), info.Size()
+			}
+		}
+		h.mu.Unlock()
+		if err != nil && onError != nil {
+			onError(err)
+		}
+	}
+}
+
+// load loads the model file, with its description taken before loading.
+func (h *Holder) load() (*Forest, os.FileInfo, error) {
+	info, err := os.Stat(h.path)
+	if err != nil {
+		return nil, nil, err
+	}
+	f := &Forest{}
+	if err := f.Load(h.path); err != nil {
+		return nil, nil, err
+	}
+	if !f.Trained || !f.Tested {
+		return nil, nil, errors.New("cannot load - model has not been trained and tested yet")
+	}
+	return f, info, nil
+}
+
+// nbFeatures returns the number of features of the forest. Models saved
+// before it was recorded are assumed to have as many features as the highest
+// attribute used by their trees.
+func nbFeatures(f *Forest) int {
+	if f.NbFeatures > 0 {
+		return f.NbFeatures
+	}
+	var max int
+	var walk func(n *Node)
+	walk = func(n *Node) {
+		if n == nil {
+			return
+		}
+		if n.Attribute+1 > max {
+			max = n.Attribute + 1
+		}
+		if !n.External {
+			walk(n.Left)
+			walk(n.Right)
+		}
+	}
+	for _, t := range f.Trees {
+		walk(t.Root)
+	}
+	return max
+}
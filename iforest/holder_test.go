@@ -0,0 +1,159 @@
+package iforest
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+func savedForest(t *testing.T, path string, nbTrees int, X [][]float64) *Forest {
+	f := NewForest(nbTrees, 32, 0.05)
+	f.Seed = int64(nbTrees)
+	f.Train(X)
+	f.Test(X)
+	if err := f.Save(path); err != nil {
+		t.Fatalf("Forest.Save() error = %v", err)
+	}
+	return f
+}
+
+func TestNewHolder(t *testing.T) {
+	_, X := randomData32(100, 3)
+	dir := t.TempDir()
+
+	if _, err := NewHolder(filepath.Join(dir, "missing.json")); err == nil {
+		t.Errorf("NewHolder() with a missing file should fail")
+	}
+	untrained := filepath.Join(dir, "untrained.json")
+	NewForest(10, 32, 0.05).Save(untrained)
+	if _, err := NewHolder(untrained); err == nil {
+		t.Errorf("NewHolder() with an untrained model should fail")
+	}
+
+	path := filepath.Join(dir, "model.json")
+	f := savedForest(t, path, 10, X)
+	h, err := NewHolder(path)
+	if err != nil {
+		t.Fatalf("NewHolder() error = %v", err)
+	}
+	if h.Path() != path || h.Features() != 3 {
+		t.Errorf("NewHolder() path = %v, features = %v", h.Path(), h.Features())
+	}
+	wantLabels, wantScores, _ := f.Predict(X)
+	labels, scores, err := h.Predict(X)
+	if err != nil || !reflect.DeepEqual(labels, wantLabels) || !reflect.DeepEqual(scores, wantScores) {
+		t.Errorf("Holder.Predict() = %v, %v, %v, want %v, %v", labels, scores, err, wantLabels, wantScores)
+	}
+	if _, parallel, _ := h.PredictParallel(X, 4); !reflect.DeepEqual(parallel, wantScores) {
+		t.Errorf("Holder.PredictParallel() = %v, want %v", parallel, wantScores)
+	}
+}
+
+func TestHolder_Reload(t *testing.T) {
+	_, X := randomData32(100, 3)
+	_, X2 := randomData32(100, 2)
+	path := filepath.Join(t.TempDir(), "model.json")
+	savedForest(t, path, 10, X)
+	h, _ := NewHolder(path)
+	old := h.Forest()
+
+	// predictions keep running while the model is swapped
+	var wg sync.WaitGroup
+	stop := make(chan struct{})
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for {
+			select {
+			case <-stop:
+				return
+			default:
+			}
+			if _, scores, err := h.Predict(X); err != nil || len(scores) != len(X) {
+				t.Errorf("Holder.Predict() during reload = %v scores, error %v", len(scores), err)
+				return
+			}
+		}
+	}()
+
+	savedForest(t, path, 20, X)
+	if err := h.Reload(); err != nil {
+		t.Fatalf("Holder.Reload() error = %v", err)
+	}
+	if len(h.Forest().Trees) != 20 || len(old.Trees) != 10 {
+		t.Errorf("Holder.Reload() serves %v trees, previous model has %v", len(h.Forest().Trees), len(old.Trees))
+	}
+
+	savedForest(t, path, 30, X2)
+	if err := h.Reload(); err == nil {
+		t.Errorf("Holder.Reload() with another number of features should fail")
+	}
+	os.WriteFile(path, []byte("{"), 0644)
+	if err := h.Reload(); err == nil {
+		t.Errorf("Holder.Reload() with an invalid file should fail")
+	}
+	if len(h.Forest().Trees) != 20 {
+		t.Errorf("Holder.Reload() failures swapped the model, %v trees served", len(h.Forest().Trees))
+	}
+	close(stop)
+	wg.Wait()
+}
+
+func TestHolder_Watch(t *testing.T) {
+	_, X := randomData32(100, 3)
+	path := filepath.Join(t.TempDir(), "model.json")
+	savedForest(t, path, 10, X)
+	h, _ := NewHolder(path)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	errs := make(chan error, 10)
+	done := make(chan struct{})
+	go func() {
+		h.Watch(ctx, time.Millisecond, func(err error) { errs <- err })
+		close(done)
+	}()
+
+	savedForest(t, path, 20, X)
+	deadline := time.Now().Add(5 * time.Second)
+	for len(h.Forest().Trees) != 20 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	if len(h.Forest().Trees) != 20 {
+		t.Errorf("Holder.Watch() did not reload the changed model")
+	}
+
+	_, X2 := randomData32(100, 2)
+	savedForest(t, path, 30, X2)
+	select {
+	case err := <-errs:
+		if err == nil {
+			t.Errorf("Holder.Watch() reported a nil error")
+		}
+	case <-time.After(5 * time.Second):
+		t.Errorf("Holder.Watch() did not report the refused model")
+	}
+	if len(h.Forest().Trees) != 20 {
+		t.Errorf("Holder.Watch() swapped in a model with another number of features")
+	}
+
+	cancel()
+	<-done
+}
+
+func Test_nbFeatures(t *testing.T) {
+	_, X := randomData32(100, 4)
+	f := NewForest(20, 32, 0.05)
+	f.Train(X)
+	if got := nbFeatures(f); got != 4 {
+		t.Errorf("nbFeatures() = %v, want 4", got)
+	}
+	// models saved before the number of features was recorded
+	f.NbFeatures = 0
+	if got := nbFeatures(f); got != 4 {
+		t.Errorf("nbFeatures() without NbFeatures = %v, want 4", got)
+	}
+}
@@ -37,6 +37,37 @@ type Forest struct {
 	Labels          []int
 	Trained         bool
 	Tested          bool
+	ScoreSketch     *ScoreSketch
+	Calibration     *Calibration
+
+	// NbFeatures is the number of attributes of the training data, 0 for
+	// models trained before it was recorded.
+	NbFeatures int
+
+	// Schema names the features of the vectors. It is optional and used by
+	// PredictRecords to map named fields to attributes.
+	Schema *Schema
+
+	// Aggregation combines the path lengths of the trees, TrimRatio and
+	// TreeWeights are the parameters of the trimmed mean and of the weighted
+	// mean.
+	Aggregation Aggregation
+	TrimRatio   float64
+	TreeWeights []float64
+
+	// Seed makes the training reproducible when it is not 0. Otherwise trees
+	// are built with the top-level functions of math/rand.
+	Seed int64
+
+	// Thresholder chooses AnomalyBound during the testing stage. When nil,
+	// the bound is chosen with Contamination(AnomalyRatio). The strategy is
+	// not saved with the model, only the bound it has chosen.
+	Thresholder Thresholder `json:"-"`
+
+	// TunedBound is the bound chosen by TuneBound. Unlike a Thresholder, it
+	// is saved with the model and kept by following tests, until another
+	// strategy is chosen.
+	TunedBound *float64 `json:",omitempty"`
 }
 
 // NewForest initializes Forest structure.
@@ -55,17 +86,23 @@ func NewForest(nbTrees, subsamplingSize int, anomalyRatio float64) *Forest {
 // Train creates the collection of trees in the forest. This is the training
 // stage of the algorithm.
 func (f *Forest) Train(X [][]float64) {
+	f.TrainMatrix(RowSlices(X))
+}
+
+// TrainMatrix does the same as Train but reads the data from a Matrix.
+func (f *Forest) TrainMatrix(X Matrix) {
 
-	mx := make(map[int][]float64, len(X))
-	for i := 0; i < len(X); i++ {
-		mx[i] = X[i]
+	rnd := globalRand
+	if f.Seed != 0 {
+		rnd = rand.New(rand.NewSource(f.Seed))
 	}
 
+	xRows, xCols := X.Dims()
+	f.NbFeatures = xCols
 	for i := 0; i < f.NbTrees; i++ {
-		subsamplesIndicies := f.createSubsamplesWithoutReplacement(mx)
-		MaxDepth = f.HeightLimit
+		subsamplesIndicies := f.createSubsamplesWithoutReplacement(xRows, rnd)
 		f.Trees[i] = Tree{}
-		f.Trees[i].BuildTree(X, subsamplesIndicies)
+		f.Trees[i].buildTree(X, subsamplesIndicies, f.HeightLimit, rnd)
 	}
 	f.Trained = true
 }
@@ -74,70 +111,57 @@ func (f *Forest) Train(X [][]float64) {
 // dataset (should be used with the same set as in training) and chooses anomaly
 // score that will be the bound for detecting anomalies.
 func (f *Forest) Test(X [][]float64) error {
+	return f.TestMatrix(RowSlices(X))
+}
+
+// TestMatrix does the same as Test but reads the data from a Matrix.
+func (f *Forest) TestMatrix(X Matrix) error {
 
 	if !f.Trained {
 		return errors.New("cannot start testing phase - model has not been trained yet")
 	}
-
-	for i := 0; i < len(X); i++ {
-		var sumPathLength float64
-		sumPathLength = 0.0
-		for j := 0; j < len(f.Trees); j++ {
-			path := PathLength(X[i], 0, f.Trees[j].Root)
-			sumPathLength += path
-		}
-		averagePath := sumPathLength / float64(len(f.Trees))
-		s := 0.5 - math.Pow(2, (-averagePath/f.CSubSampl))
-		f.AnomalyScores[i] = s
+	if err := f.checkAggregation(); err != nil {
+		return err
 	}
 
-	sorted := sortMap(f.AnomalyScores)
-	anomFloor := int(math.Floor(f.AnomalyRatio * float64(len(X))))
-	anomCeil := int(math.Ceil(f.AnomalyRatio * float64(len(X))))
-	f.AnomalyBound = (sorted[anomFloor].Value + sorted[anomCeil].Value) / 2
-
-	f.Labels = make([]int, len(X))
-	for i := 0; i < len(X); i++ {
-		if f.AnomalyScores[i] < f.AnomalyBound {
-			f.Labels[i] = 1
-		} else {
-			f.Labels[i] = 0
-		}
+	xRows, _ := X.Dims()
+	f.AnomalyScores = make(map[int]float64, xRows)
+	for i := 0; i < xRows; i++ {
+		f.AnomalyScores[i] = f.score(X, i)
 	}
 
-	f.Tested = true
-
-	return nil
+	return f.computeBound(xRows)
 
 }
 
 // Predict computes anomaly scores for given dataset and classifies each vector
 // as 'normal' or 'anomaly'.
 func (f *Forest) Predict(X [][]float64) ([]int, []float64, error) {
+	return f.PredictMatrix(RowSlices(X))
+}
+
+// PredictMatrix does the same as Predict but reads the data from a Matrix.
+func (f *Forest) PredictMatrix(X Matrix) ([]int, []float64, error) {
 
 	if !f.Trained {
 		return nil, nil, errors.New("cannot predict - model has not been trained yet")
 	}
+	if err := f.checkAggregation(); err != nil {
+		return nil, nil, err
+	}
 	if !f.Tested {
 		return nil, nil, errors.New("cannot predict - model has not been tested yet")
 	}
 
-	labels := make([]int, len(X))
-	scores := make([]float64, len(X))
-
-	for i := 0; i < len(X); i++ {
-		var sumPathLength float64
-		for j := 0; j < len(f.Trees); j++ {
-			path := PathLength(X[i], 0, f.Trees[j].Root)
-			sumPathLength += path
-		}
-		averagePath := sumPathLength / float64(len(f.Trees))
-		s := 0.5 - math.Pow(2, (-averagePath/f.CSubSampl))
+	xRows, _ := X.Dims()
+	labels := make([]int, xRows)
+	scores := make([]float64, xRows)
 
-		scores[i] = s
+	for i := 0; i < xRows; i++ {
+		scores[i] = f.score(X, i)
 	}
 
-	for i := 0; i < len(X); i++ {
+	for i := 0; i < xRows; i++ {
 		if scores[i] < f.AnomalyBound {
 			labels[i] = 1
 		} else {
@@ -151,92 +175,98 @@ func (f *Forest) Predict(X [][]float64) ([]int, []float64, error) {
 
 // TestParallel does the same as Test but using multiple go routines
 func (f *Forest) TestParallel(X [][]float64, routinesNumber int) error {
+	return f.TestParallelMatrix(RowSlices(X), routinesNumber)
+}
+
+// TestParallelMatrix does the same as TestParallel but reads the data from a
+// Matrix.
+func (f *Forest) TestParallelMatrix(X Matrix, routinesNumber int) error {
 
 	if !f.Trained {
 		return errors.New("cannot start testing phase - model has not been trained yet")
 	}
+	if err := f.checkAggregation(); err != nil {
+		return err
+	}
 
-	if routinesNumber > len(X) || routinesNumber == 0 {
+	xRows, _ := X.Dims()
+	if routinesNumber > xRows || routinesNumber == 0 {
 		return errors.New("number of routines cannot be bigger than nubmer of vectors or equal to 0")
 	}
 	var CAnomalyScores sync.Map
 	var wg sync.WaitGroup
 	wg.Add(routinesNumber)
-	vectorsPerRoutine := int(len(X) / routinesNumber)
+	vectorsPerRoutine := int(xRows / routinesNumber)
 
 	for j := 0; j < routinesNumber; j++ {
-		go f.computeAnomalies(X, j*vectorsPerRoutine, j*vectorsPerRoutine+vectorsPerRoutine, &wg, &CAnomalyScores)
+		stop := j*vectorsPerRoutine + vectorsPerRoutine
+		if j == routinesNumber-1 {
+			// the last routine also takes the remaining vectors
+			stop = xRows
+		}
+		go f.computeAnomalies(X, j*vectorsPerRoutine, stop, &wg, &CAnomalyScores)
 	}
 	wg.Wait()
 
+	f.AnomalyScores = make(map[int]float64, xRows)
 	CAnomalyScores.Range(func(key, value interface{}) bool {
 		f.AnomalyScores[key.(int)] = value.(float64)
 		return true
 	})
 
-	sorted := sortMap(f.AnomalyScores)
-	anomFloor := int(math.Floor(f.AnomalyRatio * float64(len(X))))
-	anomCeil := int(math.Ceil(f.AnomalyRatio * float64(len(X))))
-	f.AnomalyBound = (sorted[anomFloor].Value + sorted[anomCeil].Value) / 2
-
-	f.Labels = make([]int, len(X))
-	for i := 0; i < len(X); i++ {
-		if f.AnomalyScores[i] < f.AnomalyBound {
-			f.Labels[i] = 1
-		} else {
-			f.Labels[i] = 0
-		}
-	}
-
-	f.Tested = true
-
-	return nil
+	return f.computeBound(xRows)
 }
 
 // PredictParallel computes anomaly scores for given dataset and classifies each vector
 // as 'normal' or 'anomaly'. Uses  multiple go routines to make computation faster.
 func (f *Forest) PredictParallel(X [][]float64, routinesNumber int) ([]int, []float64, error) {
+	return f.PredictParallelMatrix(RowSlices(X), routinesNumber)
+}
+
+// PredictParallelMatrix does the same as PredictParallel but reads the data
+// from a Matrix.
+func (f *Forest) PredictParallelMatrix(X Matrix, routinesNumber int) ([]int, []float64, error) {
 
 	if !f.Trained {
 		return nil, nil, errors.New("cannot predict - model has not been trained yet")
 	}
+	if err := f.checkAggregation(); err != nil {
+		return nil, nil, err
+	}
 
 	if !f.Tested {
 		return nil, nil, errors.New("cannot predict - model has not been tested yet")
 	}
 
-	if routinesNumber > len(X) || routinesNumber == 0 {
+	xRows, _ := X.Dims()
+	if routinesNumber > xRows || routinesNumber == 0 {
 		return nil, nil, errors.New("number of routines cannot be bigger than nubmer of vectors or equal to 0")
 	}
 
-	labels := make([]int, len(X))
-	scores := make([]float64, len(X))
+	labels := make([]int, xRows)
+	scores := make([]float64, xRows)
 
 	var wg sync.WaitGroup
 	wg.Add(routinesNumber)
-	vectorsPerRoutine := int(len(X) / routinesNumber)
+	vectorsPerRoutine := int(xRows / routinesNumber)
 
 	for j := 0; j < routinesNumber; j++ {
+		stop := j*vectorsPerRoutine + vectorsPerRoutine
+		if j == routinesNumber-1 {
+			// the last routine also takes the remaining vectors
+			stop = xRows
+		}
 		go func(start, stop int) {
 			for i := start; i < stop; i++ {
-				var sumPathLength float64
-				sumPathLength = 0.0
-				for j := 0; j < len(f.Trees); j++ {
-					path := PathLength(X[i], 0, f.Trees[j].Root)
-					sumPathLength += path
-
-				}
-				averagePath := sumPathLength / float64(len(f.Trees))
-				s := 0.5 - math.Pow(2, (-averagePath/f.CSubSampl))
-				scores[i] = s
+				scores[i] = f.score(X, i)
 			}
 
 			wg.Done()
-		}(j*vectorsPerRoutine, j*vectorsPerRoutine+vectorsPerRoutine)
+		}(j*vectorsPerRoutine, stop)
 	}
 	wg.Wait()
 
-	for i := 0; i < len(X); i++ {
+	for i := 0; i < xRows; i++ {
 		if scores[i] < f.AnomalyBound {
 			labels[i] = 1
 		} else {
@@ -248,6 +278,124 @@ func (f *Forest) PredictParallel(X [][]float64, routinesNumber int) ([]int, []fl
 
 }
 
+// PathLengths computes the path length of given vector in each tree of the
+// forest, in the order of Trees.
+func (f *Forest) PathLengths(x []float64) ([]float64, error) {
+	if !f.Trained {
+		return nil, errors.New("cannot compute path lengths - model has not been trained yet")
+	}
+	paths := make([]float64, len(f.Trees))
+	for j := 0; j < len(f.Trees); j++ {
+		paths[j] = f.Trees[j].pathLength(vector(x), 0)
+	}
+	return paths, nil
+}
+
+// AveragePathLength computes the average path length of each vector over the
+// trees, E[h(x)], before its normalisation into an anomaly score. Path lengths
+// are combined with the Aggregation of the forest.
+func (f *Forest) AveragePathLength(X [][]float64) ([]float64, error) {
+	return f.AveragePathLengthMatrix(RowSlices(X))
+}
+
+// AveragePathLengthMatrix does the same as AveragePathLength but reads the data
+// from a Matrix.
+func (f *Forest) AveragePathLengthMatrix(X Matrix) ([]float64, error) {
+	if !f.Trained {
+		return nil, errors.New("cannot compute path lengths - model has not been trained yet")
+	}
+	if err := f.checkAggregation(); err != nil {
+		return nil, err
+	}
+	xRows, _ := X.Dims()
+	paths := make([]float64, xRows)
+	for i := 0; i < xRows; i++ {
+		paths[i] = f.averagePath(X, i)
+	}
+	return paths, nil
+}
+
+// PathScore normalises an average path length into an anomaly score, as done
+// for the scores of Test and Predict.
+func (f *Forest) PathScore(averagePath float64) float64 {
+	return 0.5 - math.Pow(2, (-averagePath/f.CSubSampl))
+}
+
+// score computes the anomaly score of the i-th row of the matrix.
+func (f *Forest) score(X Matrix, i int) float64 {
+	return f.PathScore(f.averagePath(X, i))
+}
+
+// averagePath computes the path length of the i-th row of the matrix,
+// aggregated over the trees.
+func (f *Forest) averagePath(X Matrix, i int) float64 {
+	if f.Aggregation != MeanAggregation {
+		paths := make([]float64, len(f.Trees))
+		for j := 0; j < len(f.Trees); j++ {
+			paths[j] = f.Trees[j].pathLength(X, i)
+		}
+		return f.aggregate(paths)
+	}
+
+	var sumPathLength float64
+	for j := 0; j < len(f.Trees); j++ {
+		sumPathLength += f.Trees[j].pathLength(X, i)
+	}
+	return sumPathLength / float64(len(f.Trees))
+}
+
+// computeBound chooses the anomaly bound from the scores of the n tested
+// vectors and labels them.
+func (f *Forest) computeBound(n int) error {
+
+	sorted := make([]float64, 0, len(f.AnomalyScores))
+	for _, el := range sortMap(f.AnomalyScores) {
+		sorted = append(sorted, el.Value)
+	}
+
+	sketch, err := NewScoreSketch(sorted, DefaultSketchSize)
+	if err != nil {
+		return err
+	}
+	f.ScoreSketch = sketch
+
+	// a calibration fitted on labelled data is kept, the gaussian one is
+	// refitted and left empty when the scores do not allow it
+	if f.Calibration == nil || f.Calibration.Method == GaussianCalibration {
+		f.Calibration, _ = FitGaussian(sorted)
+	}
+
+	t := f.Thresholder
+	if t == nil && f.TunedBound != nil {
+		t = FixedScore(*f.TunedBound)
+	}
+	if t == nil {
+		t = Contamination(f.AnomalyRatio)
+	}
+	bound, err := t.Threshold(sorted)
+	if err != nil {
+		return err
+	}
+	f.AnomalyBound = bound
+	f.label(n)
+
+	f.Tested = true
+
+	return nil
+}
+
+// label labels the n tested vectors with the current anomaly bound.
+func (f *Forest) label(n int) {
+	f.Labels = make([]int, n)
+	for i := 0; i < n; i++ {
+		if f.AnomalyScores[i] < f.AnomalyBound {
+			f.Labels[i] = 1
+		} else {
+			f.Labels[i] = 0
+		}
+	}
+}
+
 // createSubsamples randomly chooses samples from the whole dataset to create
 // subset of the size equals to SubsamplingSize. This subset is used in the
 // training phase. Using this function one sample may occur several times in
@@ -263,33 +411,37 @@ func (f *Forest) createSubsamples(X [][]float64) []int { //use 'without replacem
 	return subsamplesIds
 }
 
-func (f *Forest) createSubsamplesWithoutReplacement(X map[int][]float64) []int {
+// createSubsamplesWithoutReplacement chooses SubsamplingSize distinct indices
+// among the n first ones, or all of them when n is lower than SubsamplingSize.
+func (f *Forest) createSubsamplesWithoutReplacement(n int, rnd *rand.Rand) []int {
 
-	subsamplesIds := make([]int, f.SubsamplingSize)
-	currentSize := 0
-	for i := range X {
-		subsamplesIds[currentSize] = i
-		currentSize++
-		if currentSize >= f.SubsamplingSize {
-			return subsamplesIds
+	size := f.SubsamplingSize
+	if n < size {
+		size = n
+	}
+	subsamplesIds := make([]int, size)
+	// partial Fisher-Yates shuffle, only swapped indices are stored
+	swapped := make(map[int]int, size)
+	for i := 0; i < size; i++ {
+		j := i + rnd.Intn(n-i)
+		vi, ok := swapped[i]
+		if !ok {
+			vi = i
+		}
+		vj, ok := swapped[j]
+		if !ok {
+			vj = j
 		}
+		subsamplesIds[i] = vj
+		swapped[j] = vi
 	}
 	return subsamplesIds
 }
 
-func (f *Forest) computeAnomalies(X [][]float64, start, stop int, wg *sync.WaitGroup, as *sync.Map) {
+func (f *Forest) computeAnomalies(X Matrix, start, stop int, wg *sync.WaitGroup, as *sync.Map) {
 
 	for i := start; i < stop; i++ {
-		var sumPathLength float64
-		sumPathLength = 0.0
-		for j := 0; j < len(f.Trees); j++ {
-			path := PathLength(X[i], 0, f.Trees[j].Root)
-			sumPathLength += path
-
-		}
-		averagePath := sumPathLength / float64(len(f.Trees))
-		s := 0.5 - math.Pow(2, (-averagePath/f.CSubSampl))
-		as.Store(i, s)
+		as.Store(i, f.score(X, i))
 	}
 
 	wg.Done()
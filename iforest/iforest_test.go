@@ -1,6 +1,7 @@
 package iforest
 
 import (
+	"math"
 	"reflect"
 	"testing"
 )
@@ -25,18 +26,26 @@ func TestForest_createSubsamplesWithoutReplacement(t *testing.T) {
 
 	tests := []struct {
 		f    *Forest
-		X    map[int][]float64
+		n    int
 		want int
 	}{
-		{f: NewForest(1, 2, 0.1), X: map[int][]float64{0: {1, 1, 1}, 1: {1, 1, 1}, 2: {1, 1, 1}}, want: 2},
-		{f: NewForest(1, 8, 0.1), X: map[int][]float64{0: {1, 1, 1}, 1: {1, 1, 1}, 2: {1, 1, 1}, 3: {1, 2, 3}, 4: {2, 3, 4}, 5: {2, 4, 5}}, want: 8},
+		{f: NewForest(1, 2, 0.1), n: 3, want: 2},
+		{f: NewForest(1, 8, 0.1), n: 6, want: 6},
 	}
 
 	for _, tt := range tests {
 
-		if got := tt.f.createSubsamplesWithoutReplacement(tt.X); len(got) != tt.want {
+		got := tt.f.createSubsamplesWithoutReplacement(tt.n, globalRand)
+		if len(got) != tt.want {
 			t.Errorf("Forest.createSubsamplesWithoutReplacement() = %v, want %v", got, tt.want)
 		}
+		seen := make(map[int]bool)
+		for _, id := range got {
+			if seen[id] {
+				t.Errorf("Forest.createSubsamplesWithoutReplacement() = %v, want distinct indices", got)
+			}
+			seen[id] = true
+		}
 
 	}
 }
@@ -160,6 +169,29 @@ func TestForest_PredictParallel(t *testing.T) {
 	}
 }
 
+func TestForest_PredictParallelRemainder(t *testing.T) {
+	X := [][]float64{{1, 1}, {1, 2}, {2, 1}, {2, 2}, {1, 1}, {10, 10}, {3, 2}}
+
+	f := NewForest(10, 4, 0.2)
+	f.Train(X)
+	f.Test(X)
+	labels, scores, _ := f.Predict(X)
+	parallelLabels, parallelScores, err := f.PredictParallel(X, 3)
+	if err != nil {
+		t.Fatalf("Forest.PredictParallel() error = %v", err)
+	}
+	if !reflect.DeepEqual(scores, parallelScores) || !reflect.DeepEqual(labels, parallelLabels) {
+		t.Errorf("Forest.PredictParallel() = %v, %v, want %v, %v", parallelLabels, parallelScores, labels, scores)
+	}
+
+	if err := f.TestParallel(X, 3); err != nil {
+		t.Fatalf("Forest.TestParallel() error = %v", err)
+	}
+	if len(f.AnomalyScores) != len(X) {
+		t.Errorf("Forest.TestParallel() scored %v vectors, want %v", len(f.AnomalyScores), len(X))
+	}
+}
+
 func TestForest_TestParallel(t *testing.T) {
 
 	tests := []struct {
@@ -226,3 +258,71 @@ func TestForest_Load(t *testing.T) {
 
 	}
 }
+
+func TestForest_Seed(t *testing.T) {
+	X := [][]float64{{1, 1}, {1, 2}, {2, 1}, {2, 2}, {1, 1}, {10, 10}, {3, 2}, {-5, 1}}
+
+	scores := make([][]float64, 2)
+	for i := range scores {
+		f := NewForest(10, 4, 0.1)
+		f.Seed = 42
+		f.Train(X)
+		f.Test(X)
+		_, scores[i], _ = f.Predict(X)
+	}
+	if !reflect.DeepEqual(scores[0], scores[1]) {
+		t.Errorf("Forest.Train() with the same seed gives scores %v and %v", scores[0], scores[1])
+	}
+
+	f := NewForest(1, 5, 0.1)
+	ids := f.createSubsamplesWithoutReplacement(10, globalRand)
+	seen := make(map[int]bool)
+	for _, id := range ids {
+		if seen[id] || id < 0 || id >= 10 {
+			t.Errorf("Forest.createSubsamplesWithoutReplacement() = %v, want distinct indices", ids)
+		}
+		seen[id] = true
+	}
+}
+
+func TestForest_PathLengths(t *testing.T) {
+	X := [][]float64{{1, 1}, {1, 2}, {2, 1}, {2, 2}, {1, 1}, {10, 10}}
+
+	f := NewForest(10, 4, 0.2)
+	if _, err := f.PathLengths(X[0]); err == nil {
+		t.Errorf("Forest.PathLengths() should fail before training")
+	}
+	if _, err := f.AveragePathLength(X); err == nil {
+		t.Errorf("Forest.AveragePathLength() should fail before training")
+	}
+	f.Train(X)
+	f.Test(X)
+
+	averages, err := f.AveragePathLength(X)
+	if err != nil {
+		t.Fatalf("Forest.AveragePathLength() error = %v", err)
+	}
+	_, scores, _ := f.Predict(X)
+	for i := range X {
+		paths, err := f.PathLengths(X[i])
+		if err != nil {
+			t.Fatalf("Forest.PathLengths() error = %v", err)
+		}
+		if len(paths) != len(f.Trees) {
+			t.Fatalf("Forest.PathLengths() = %v, want %v paths", paths, len(f.Trees))
+		}
+		var sum float64
+		for j, p := range paths {
+			if want := PathLength(X[i], 0, f.Trees[j].Root); p != want {
+				t.Errorf("Forest.PathLengths() tree %d = %v, want %v", j, p, want)
+			}
+			sum += p
+		}
+		if math.Abs(sum/float64(len(paths))-averages[i]) > 1e-12 {
+			t.Errorf("Forest.AveragePathLength() = %v, want mean of %v", averages[i], paths)
+		}
+		if got := f.PathScore(averages[i]); got != scores[i] {
+			t.Errorf("Forest.PathScore() = %v, want %v", got, scores[i])
+		}
+	}
+}
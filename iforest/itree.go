@@ -5,13 +5,27 @@ import (
 	"math/rand"
 )
 
-// MaxDepth limits the depth of trees, is computed during initialization based
-// on the subsampling size
+// MaxDepth limits the depth of trees built with BuildTree. Forests limit the
+// depth of their trees with their HeightLimit instead.
 var MaxDepth int
 
+// globalSource draws numbers from the top-level functions of math/rand, which
+// are safe for concurrent use.
+type globalSource struct{}
+
+func (globalSource) Int63() int64   { return rand.Int63() }
+func (globalSource) Uint64() uint64 { return rand.Uint64() }
+func (globalSource) Seed(int64)     {}
+
+// globalRand is used when no seed is given.
+var globalRand = rand.New(globalSource{})
+
 // Tree is base structure for the iTree
 type Tree struct {
 	Root *Node
+	// Scale multiplies the path lengths of the tree, 0 standing for 1. It
+	// normalises trees merged from a forest with another subsampling size.
+	Scale float64
 }
 
 // Node is a structure for iNode
@@ -28,47 +42,56 @@ type Node struct {
 // BuildTree builds decision tree for given data, attributes and values used for
 // splits are chosen randomly
 func (t *Tree) BuildTree(X [][]float64, ids []int) error {
-	xCols := len(X[0])
-	att := findAttribute(xCols)
-	split := findSplit(X, ids, att)
+	return t.BuildTreeMatrix(RowSlices(X), ids)
+}
+
+// BuildTreeMatrix does the same as BuildTree but reads the data from a Matrix.
+func (t *Tree) BuildTreeMatrix(X Matrix, ids []int) error {
+	t.buildTree(X, ids, MaxDepth, globalRand)
+	return nil
+}
+
+// buildTree builds the tree with numbers drawn from rnd, up to maxDepth.
+func (t *Tree) buildTree(X Matrix, ids []int, maxDepth int, rnd *rand.Rand) {
+	_, xCols := X.Dims()
+	att := findAttribute(xCols, rnd)
+	split := findSplit(X, ids, att, rnd)
 
 	root := &Node{Split: split, Attribute: att, Size: len(ids)}
 
 	indiciesSmaller, indiciesBigger := splitMatrix(X, split, att, ids)
 
 	root.External = false
-	root.Left = nextNode(X, indiciesSmaller, 1)
-	root.Right = nextNode(X, indiciesBigger, 1)
+	root.Left = nextNode(X, indiciesSmaller, 1, maxDepth, rnd)
+	root.Right = nextNode(X, indiciesBigger, 1, maxDepth, rnd)
 
 	t.Root = root
-
-	return nil
 }
 
 // nextNode finds the attribute and split value for current node and creates
 // next leaves(nodes) of the tree.  It finishes when no more data is available
 // or when the tree reaches its maximum depth.
-func nextNode(X [][]float64, indicies []int, d int) *Node {
-	xCols := len(X[0])
+func nextNode(X Matrix, indicies []int, d, maxDepth int, rnd *rand.Rand) *Node {
+	_, xCols := X.Dims()
 
 	var c float64
 	if len(indicies) > 1 {
 		c = computeC(float64(len(indicies)))
 	}
 
-	if len(indicies) <= 1 || d >= MaxDepth {
+	if len(indicies) <= 1 || d >= maxDepth {
 		return &Node{External: true, Size: len(indicies), C: c}
 	}
 
-	att := findAttribute(xCols)
-	split := findSplit(X, indicies, att)
+	att := findAttribute(xCols, rnd)
+	split := findSplit(X, indicies, att, rnd)
 
 	newNode := &Node{Attribute: att, Split: split, External: false, Size: len(indicies), C: c}
 
 	indiciesSmaller, indiciesBigger := splitMatrix(X, split, att, indicies)
-	newNode.Left = nextNode(X, indiciesSmaller, d+1)
+	newNode.Left = nextNode(X, indiciesSmaller, d+1, maxDepth, rnd)
 
-	newNode.Right = nextNode(X, indiciesBigger, d+1)
+	newNode.Right = nextNode(X, indiciesBigger, d+1, maxDepth, rnd)
 
 	return newNode
 
@@ -95,13 +118,27 @@ func nextNode(X [][]float64, indicies []int, d int) *Node {
 // The result is number of edges from the root to terminating node
 // plus adjustment value - c(Size) as described in algorithm specification.
 func PathLength(V []float64, pathLength int, node *Node) float64 {
+	return pathLengthAt(vector(V), 0, pathLength, node)
+}
+
+// pathLength computes the scaled path length of the i-th row of the matrix.
+func (t *Tree) pathLength(X Matrix, i int) float64 {
+	h := pathLengthAt(X, i, 0, t.Root)
+	if t.Scale != 0 {
+		h *= t.Scale
+	}
+	return h
+}
+
+// pathLengthAt computes the length of the path for the i-th row of the matrix.
+func pathLengthAt(X Matrix, i int, pathLength int, node *Node) float64 {
 
 	var currentNode, nextNode *Node
 	currentNode = node
 
 	for {
 		pathLength++
-		if V[currentNode.Attribute] < currentNode.Split {
+		if X.At(i, currentNode.Attribute) < currentNode.Split {
 			nextNode = currentNode.Left
 		} else {
 			nextNode = currentNode.Right
@@ -123,13 +160,13 @@ func PathLength(V []float64, pathLength int, node *Node) float64 {
 
 // splitMatrix divides matrix on two parts based on chosen attribute and split
 // value
-func splitMatrix(X [][]float64, split float64, attribute int, ind []int) ([]int, []int) {
+func splitMatrix(X Matrix, split float64, attribute int, ind []int) ([]int, []int) {
 
 	smaller := make([]int, 0)
 	bigger := make([]int, 0)
 
 	for _, val := range ind {
-		if X[val][attribute] < split {
+		if X.At(val, attribute) < split {
 			smaller = append(smaller, val)
 		} else {
 			bigger = append(bigger, val)
@@ -140,37 +177,39 @@ func splitMatrix(X [][]float64, split float64, attribute int, ind []int) ([]int,
 }
 
 // findAttribute randomly choose the attribute for the split
-func findAttribute(nbAtt int) int {
-	return rand.Intn(nbAtt)
+func findAttribute(nbAtt int, rnd *rand.Rand) int {
+	return rnd.Intn(nbAtt)
 
 }
 
 // findSplit randomly choose value of the split. This value is always between
 // lowest and highest value among the attribute values.
-func findSplit(X [][]float64, ind []int, att int) float64 {
+func findSplit(X Matrix, ind []int, att int, rnd *rand.Rand) float64 {
 	max := -math.MaxFloat64
 	min := math.MaxFloat64
 
 	for _, val := range ind {
-		if X[val][att] <= min {
-			min = X[val][att]
+		v := X.At(val, att)
+		if v <= min {
+			min = v
 		}
-		if X[val][att] >= max {
-			max = X[val][att]
+		if v >= max {
+			max = v
 		}
 
 	}
-	return min + (max-min)*rand.Float64()
+	return min + (max-min)*rnd.Float64()
 
 }
 
 // allSame checks if all vectors in given matrix are the same
-func allSame(X [][]float64, ind []int) bool {
+func allSame(X Matrix, ind []int) bool {
+	_, xCols := X.Dims()
 	for _, el := range ind[1:] {
 		var different bool
-		for i := 0; i < len(X[el]); i++ {
+		for i := 0; i < xCols; i++ {
 
-			if X[el][i] != X[ind[0]][i] {
+			if X.At(el, i) != X.At(ind[0], i) {
 				different = true
 			}
 		}
@@ -25,7 +25,7 @@ func Test_splitMatrix(t *testing.T) {
 	}
 	for _, tt := range tests {
 
-		got, got1 := splitMatrix(tt.args.X, tt.args.split, tt.args.attribute, tt.args.ind)
+		got, got1 := splitMatrix(RowSlices(tt.args.X), tt.args.split, tt.args.attribute, tt.args.ind)
 		if !reflect.DeepEqual(got, tt.want) {
 			t.Errorf("splitMatrix() got = %v, want %v", got, tt.want)
 		}
@@ -52,7 +52,7 @@ func Test_findSplit(t *testing.T) {
 	}
 	for _, tt := range tests {
 
-		if got := findSplit(tt.args.X, tt.args.ind, tt.args.att); got < tt.want1 || got > tt.want2 {
+		if got := findSplit(RowSlices(tt.args.X), tt.args.ind, tt.args.att, globalRand); got < tt.want1 || got > tt.want2 {
 			t.Errorf("findSplit() = %v, want between %v : %v", got, tt.want1, tt.want2)
 		}
 
@@ -71,7 +71,7 @@ func Test_findAttribute(t *testing.T) {
 	}
 	for _, tt := range tests {
 
-		if got := findAttribute(tt.nbAtt); got > tt.want {
+		if got := findAttribute(tt.nbAtt, globalRand); got > tt.want {
 			t.Errorf("findAttribute() = %v, want %v", got, tt.want)
 		}
 
@@ -131,7 +131,7 @@ func Test_allSame(t *testing.T) {
 	}
 	for _, tt := range tests {
 
-		if got := allSame(tt.args.X, tt.args.ind); got != tt.want {
+		if got := allSame(RowSlices(tt.args.X), tt.args.ind); got != tt.want {
 			t.Errorf("allSame() = %v, want %v", got, tt.want)
 		}
 
@@ -152,10 +152,9 @@ func Test_nextNode(t *testing.T) {
 		{args: args{X: [][]float64{{1, 2}, {1, 2}, {2, 2}, {3, 5}}, indicies: []int{0, 1}, d: 0}, want: &Node{Left: &Node{}, Right: &Node{}, External: false, Size: 2, C: c(float64(2))}},
 	}
 
-	MaxDepth = 2
 	for _, tt := range tests {
 
-		if got := nextNode(tt.args.X, tt.args.indicies, tt.args.d); got.C != tt.want.C || got.Left == nil || got.Right == nil || got.External != tt.want.External || got.Size != tt.want.Size {
+		if got := nextNode(RowSlices(tt.args.X), tt.args.indicies, tt.args.d, 2, globalRand); got.C != tt.want.C || got.Left == nil || got.Right == nil || got.External != tt.want.External || got.Size != tt.want.Size {
 			t.Errorf("nextNode() = %v, want %v", got, tt.want)
 		}
 
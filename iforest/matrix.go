@@ -0,0 +1,107 @@
+package iforest
+
+import "errors"
+
+// Matrix is a two dimensional dataset. Rows are data vectors and columns are
+// their attributes. It allows training and scoring on data without imposing
+// any memory layout, so buffers that already hold the values do not have to be
+// copied into [][]float64.
+type Matrix interface {
+	// Dims returns the number of rows and columns of the matrix.
+	Dims() (r, c int)
+	// At returns the value of the element at row i and column j.
+	At(i, j int) float64
+}
+
+// RowSlices adapts a slice of rows to the Matrix interface. All rows are
+// expected to have the same length.
+type RowSlices [][]float64
+
+// Dims returns the number of rows and columns of the matrix.
+func (m RowSlices) Dims() (int, int) {
+	if len(m) == 0 {
+		return 0, 0
+	}
+	return len(m), len(m[0])
+}
+
+// At returns the value of the element at row i and column j.
+func (m RowSlices) At(i, j int) float64 {
+	return m[i][j]
+}
+
+// vector is a single data vector seen as a matrix with one row.
+type vector []float64
+
+func (v vector) Dims() (int, int) {
+	return 1, len(v)
+}
+
+func (v vector) At(_, j int) float64 {
+	return v[j]
+}
+
+// RowMajor is a dense matrix stored in a flat buffer, row after row.
+type RowMajor struct {
+	data []float64
+	rows int
+	cols int
+}
+
+// NewRowMajor creates a matrix with given dimensions on top of data, which is
+// used directly without copying. The element at row i and column j is
+// data[i*cols+j].
+func NewRowMajor(rows, cols int, data []float64) (*RowMajor, error) {
+	if err := checkDims(rows, cols, data); err != nil {
+		return nil, err
+	}
+	return &RowMajor{data: data, rows: rows, cols: cols}, nil
+}
+
+// Dims returns the number of rows and columns of the matrix.
+func (m *RowMajor) Dims() (int, int) {
+	return m.rows, m.cols
+}
+
+// At returns the value of the element at row i and column j.
+func (m *RowMajor) At(i, j int) float64 {
+	return m.data[i*m.cols+j]
+}
+
+// ColMajor is a dense matrix stored in a flat buffer, column after column, as
+// produced by columnar readers.
+type ColMajor struct {
+	data []float64
+	rows int
+	cols int
+}
+
+// NewColMajor creates a matrix with given dimensions on top of data, which is
+// used directly without copying. The element at row i and column j is
+// data[j*rows+i].
+func NewColMajor(rows, cols int, data []float64) (*ColMajor, error) {
+	if err := checkDims(rows, cols, data); err != nil {
+		return nil, err
+	}
+	return &ColMajor{data: data, rows: rows, cols: cols}, nil
+}
+
+// Dims returns the number of rows and columns of the matrix.
+func (m *ColMajor) Dims() (int, int) {
+	return m.rows, m.cols
+}
+
+// At returns the value of the element at row i and column j.
+func (m *ColMajor) At(i, j int) float64 {
+	return m.data[j*m.rows+i]
+}
+
+func checkDims(rows, cols int, data []float64) error {
+	if rows < 0 || cols < 0 {
+		return errors.New("matrix dimensions cannot be negative")
+	}
+	if len(data) != rows*cols {
+		return errors.New("matrix data length does not match its dimensions")
+	}
+	return nil
+}
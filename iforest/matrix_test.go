@@ -0,0 +1,91 @@
+package iforest
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestNewRowMajor(t *testing.T) {
+	tests := []struct {
+		rows, cols int
+		data       []float64
+		wantErr    bool
+	}{
+		{rows: 2, cols: 3, data: []float64{1, 2, 3, 4, 5, 6}, wantErr: false},
+		{rows: 0, cols: 0, data: nil, wantErr: false},
+		{rows: 2, cols: 3, data: []float64{1, 2, 3, 4, 5}, wantErr: true},
+		{rows: -1, cols: 3, data: []float64{}, wantErr: true},
+	}
+	for _, tt := range tests {
+
+		if _, err := NewRowMajor(tt.rows, tt.cols, tt.data); (err != nil) != tt.wantErr {
+			t.Errorf("NewRowMajor() error = %v, wantErr %v", err, tt.wantErr)
+		}
+		if _, err := NewColMajor(tt.rows, tt.cols, tt.data); (err != nil) != tt.wantErr {
+			t.Errorf("NewColMajor() error = %v, wantErr %v", err, tt.wantErr)
+		}
+
+	}
+}
+
+func TestMatrix_At(t *testing.T) {
+	X := [][]float64{{1, 2, 3}, {4, 5, 6}}
+	rowMajor, _ := NewRowMajor(2, 3, []float64{1, 2, 3, 4, 5, 6})
+	colMajor, _ := NewColMajor(2, 3, []float64{1, 4, 2, 5, 3, 6})
+
+	tests := []struct {
+		name string
+		m    Matrix
+	}{
+		{name: "RowSlices", m: RowSlices(X)},
+		{name: "RowMajor", m: rowMajor},
+		{name: "ColMajor", m: colMajor},
+	}
+	for _, tt := range tests {
+
+		if r, c := tt.m.Dims(); r != 2 || c != 3 {
+			t.Errorf("%s.Dims() = %v, %v, want 2, 3", tt.name, r, c)
+		}
+		for i := range X {
+			for j := range X[i] {
+				if got := tt.m.At(i, j); got != X[i][j] {
+					t.Errorf("%s.At(%d, %d) = %v, want %v", tt.name, i, j, got, X[i][j])
+				}
+			}
+		}
+
+	}
+}
+
+func TestForest_PredictMatrix(t *testing.T) {
+	X := [][]float64{{1, 1}, {1, 2}, {2, 1}, {2, 2}, {1, 1}, {10, 10}}
+	rowMajor, _ := NewRowMajor(6, 2, []float64{1, 1, 1, 2, 2, 1, 2, 2, 1, 1, 10, 10})
+	colMajor, _ := NewColMajor(6, 2, []float64{1, 1, 2, 2, 1, 10, 1, 2, 1, 2, 1, 10})
+
+	f := NewForest(10, 4, 0.2)
+	f.TrainMatrix(colMajor)
+	if err := f.TestMatrix(rowMajor); err != nil {
+		t.Fatalf("Forest.TestMatrix() error = %v", err)
+	}
+
+	wantLabels, wantScores, _ := f.Predict(X)
+	for _, m := range []Matrix{rowMajor, colMajor} {
+
+		labels, scores, err := f.PredictMatrix(m)
+		if err != nil {
+			t.Fatalf("Forest.PredictMatrix() error = %v", err)
+		}
+		if !reflect.DeepEqual(labels, wantLabels) || !reflect.DeepEqual(scores, wantScores) {
+			t.Errorf("Forest.PredictMatrix() = %v, %v, want %v, %v", labels, scores, wantLabels, wantScores)
+		}
+
+		labels, scores, err = f.PredictParallelMatrix(m, 2)
+		if err != nil {
+			t.Fatalf("Forest.PredictParallelMatrix() error = %v", err)
+		}
+		if !reflect.DeepEqual(labels, wantLabels) || !reflect.DeepEqual(scores, wantScores) {
+			t.Errorf("Forest.PredictParallelMatrix() = %v, %v, want %v, %v", labels, scores, wantLabels, wantScores)
+		}
+
+	}
+}
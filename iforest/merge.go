@@ -0,0 +1,163 @@
+package iforest
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+// Merge combines the trees of forests trained on different data, for instance
+// on shards of a dataset, into one forest. Forests must have been trained on
+// the same attributes, with the same feature names if they have a schema, and
+// aggregate path lengths the same way. Parameters of the merged forest are
+// taken from the first one, path lengths of trees from forests with another
+// subsampling size are scaled so that each tree is normalised by the average
+// path length of its own subsample. The merged forest has to be tested before
+// it labels vectors with its own bound.
+func Merge(forests ...*Forest) (*Forest, error) {
+	if len(forests) == 0 {
+		return nil, errors.New("cannot merge - no forest")
+	}
+	first := forests[0]
+	var schema *Schema
+	var nbFeatures int
+	for k, f := range forests {
+		if !f.Trained {
+			return nil, fmt.Errorf("cannot merge - forest %d has not been trained yet", k)
+		}
+		if err := f.checkAggregation(); err != nil {
+			return nil, fmt.Errorf("cannot merge - forest %d: %v", k, err)
+		}
+		if f.NbFeatures != 0 {
+			if nbFeatures != 0 && f.NbFeatures != nbFeatures {
+				return nil, fmt.Errorf("cannot merge - forest %d has %d features, want %d", k, f.NbFeatures, nbFeatures)
+			}
+			nbFeatures = f.NbFeatures
+		}
+		if f.Schema != nil {
+			if schema != nil && !reflect.DeepEqual(f.Schema.Names(), schema.Names()) {
+				return nil, fmt.Errorf("cannot merge - forest %d has other feature names", k)
+			}
+			if schema == nil {
+				schema = f.Schema
+			}
+		}
+		if f.Aggregation != first.Aggregation || f.TrimRatio != first.TrimRatio {
+			return nil, fmt.Errorf("cannot merge - forest %d aggregates path lengths differently", k)
+		}
+		if f.CSubSampl <= 0 {
+			return nil, fmt.Errorf("cannot merge - forest %d has no path length normalisation", k)
+		}
+	}
+
+	m := &Forest{
+		SubsamplingSize: first.SubsamplingSize,
+		HeightLimit:     first.HeightLimit,
+		CSubSampl:       first.CSubSampl,
+		AnomalyScores:   make(map[int]float64),
+		AnomalyBound:    first.AnomalyBound,
+		AnomalyRatio:    first.AnomalyRatio,
+		Trained:         true,
+		Aggregation:     first.Aggregation,
+		TrimRatio:       first.TrimRatio,
+		NbFeatures:      nbFeatures,
+		Schema:          schema,
+	}
+	for _, f := range forests {
+		for _, t := range f.Trees {
+			if f.CSubSampl != first.CSubSampl {
+				scale := t.Scale
+				if scale == 0 {
+					scale = 1
+				}
+				t.Scale = scale * first.CSubSampl / f.CSubSampl
+			}
+			m.Trees = append(m.Trees, t)
+		}
+		if m.Aggregation == WeightedAggregation {
+			m.TreeWeights = append(m.TreeWeights, f.TreeWeights...)
+		}
+	}
+	m.NbTrees = len(m.Trees)
+	return m, nil
+}
+
+// Split shards the trees of the forest into k forests of nearly equal sizes,
+// for instance to score a dataset on several machines. Shards keep the
+// parameters of the forest and can be merged back with Merge. Their average
+// path lengths can be combined with CombinePathLengths.
+func (f *Forest) Split(k int) ([]*Forest, error) {
+	if !f.Trained {
+		return nil, errors.New("cannot split - model has not been trained yet")
+	}
+	if k < 1 || k > len(f.Trees) {
+		return nil, fmt.Errorf("cannot split %d trees in %d forests", len(f.Trees), k)
+	}
+
+	shards := make([]*Forest, k)
+	start := 0
+	for s := range shards {
+		stop := start + len(f.Trees)/k
+		if s < len(f.Trees)%k {
+			stop++
+		}
+		g := *f
+		g.Trees = append([]Tree(nil), f.Trees[start:stop]...)
+		g.NbTrees = len(g.Trees)
+		g.AnomalyScores = make(map[int]float64)
+		g.Labels = nil
+		g.Tested = false
+		f.cloneShared(&g)
+		if f.TreeWeights != nil {
+			g.TreeWeights = append([]float64(nil), f.TreeWeights[start:stop]...)
+		}
+		shards[s] = &g
+		start = stop
+	}
+	return shards, nil
+}
+
+// CombinePathLengths combines the average path lengths computed by the shards
+// of a forest, paths[s] being computed with shards[s], into the average path
+// lengths of the whole forest, which PathScore turns into anomaly scores. Only
+// the mean and the weighted aggregations can be combined.
+func CombinePathLengths(shards []*Forest, paths [][]float64) ([]float64, error) {
+	if len(shards) == 0 || len(shards) != len(paths) {
+		return nil, errors.New("cannot combine - there must be one set of path lengths per shard")
+	}
+	combined := make([]float64, len(paths[0]))
+	var total float64
+	for s, g := range shards {
+		if len(paths[s]) != len(combined) {
+			return nil, errors.New("cannot combine - shards scored different numbers of vectors")
+		}
+		var w float64
+		switch g.Aggregation {
+		case MeanAggregation:
+			w = float64(len(g.Trees))
+		case WeightedAggregation:
+			for _, tw := range g.TreeWeights {
+				w += tw
+			}
+		default:
+			return nil, errors.New("cannot combine - aggregation " + string(g.Aggregation) + " cannot be combined")
+		}
+		if g.Aggregation != shards[0].Aggregation {
+			return nil, errors.New("cannot combine - shards aggregate path lengths differently")
+		}
+		if w == 0 {
+			continue
+		}
+		for i, p := range paths[s] {
+			combined[i] += w * p
+		}
+		total += w
+	}
+	if total == 0 {
+		return nil, errors.New("cannot combine - shards have no weight")
+	}
+	for i := range combined {
+		combined[i] /= total
+	}
+	return combined, nil
+}
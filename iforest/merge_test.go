@@ -0,0 +1,199 @@
+package iforest
+
+import (
+	"encoding/json"
+	"math"
+	"testing"
+)
+
+func TestMerge(t *testing.T) {
+	_, X := randomData32(200, 3)
+	_, X2 := randomData32(200, 2)
+
+	small := NewForest(20, 32, 0.05)
+	small.Seed = 1
+	small.Train(X)
+	large := NewForest(30, 128, 0.05)
+	large.Seed = 2
+	large.Train(X)
+	other := NewForest(10, 32, 0.05)
+	other.Seed = 3
+	other.Train(X2)
+	median := NewForest(10, 32, 0.05)
+	median.Seed = 4
+	median.Train(X)
+	median.Aggregation = MedianAggregation
+	named, renamed, legacy := *small, *small, *small
+	legacy.NbFeatures = 0
+	named.Schema, _ = NewSchema([]string{"x", "y", "z"}, nil)
+	renamed.Schema, _ = NewSchema([]string{"z", "y", "x"}, nil)
+
+	tests := []struct {
+		name    string
+		forests []*Forest
+		wantErr bool
+	}{
+		{name: "none", wantErr: true},
+		{name: "untrained", forests: []*Forest{small, NewForest(10, 32, 0.05)}, wantErr: true},
+		{name: "features", forests: []*Forest{small, other}, wantErr: true},
+		{name: "features after a legacy forest", forests: []*Forest{&legacy, small, other}, wantErr: true},
+		{name: "legacy forest", forests: []*Forest{&legacy, small}},
+		{name: "aggregation", forests: []*Forest{small, median}, wantErr: true},
+		{name: "feature names", forests: []*Forest{&named, small, &renamed}, wantErr: true},
+		{name: "same size", forests: []*Forest{small, small}},
+		{name: "schema", forests: []*Forest{small, &named}},
+		{name: "different sizes", forests: []*Forest{small, large}},
+	}
+	for _, tt := range tests {
+
+		if _, err := Merge(tt.forests...); (err != nil) != tt.wantErr {
+			t.Errorf("%s: Merge() error = %v, wantErr %v", tt.name, err, tt.wantErr)
+		}
+
+	}
+
+	if m, _ := Merge(small, &named); m.Schema != named.Schema {
+		t.Errorf("Merge() schema = %v, want %v", m.Schema, named.Schema)
+	}
+
+	m, err := Merge(small, large)
+	if err != nil {
+		t.Fatalf("Merge() error = %v", err)
+	}
+	if m.NbTrees != 50 || len(m.Trees) != 50 || m.NbFeatures != 3 || m.CSubSampl != small.CSubSampl {
+		t.Fatalf("Merge() = %v trees, %v features, c = %v", len(m.Trees), m.NbFeatures, m.CSubSampl)
+	}
+	if err := m.Test(X); err != nil {
+		t.Fatalf("Forest.Test() on the merged forest error = %v", err)
+	}
+	_, scores, _ := m.Predict(X)
+	f32 := m.Float32()
+	_, scores32, _ := f32.Predict(float32Rows(X))
+	for i := range X {
+		// each tree is normalised by the average path length of its subsample
+		smallPaths, _ := small.PathLengths(X[i])
+		largePaths, _ := large.PathLengths(X[i])
+		var sum float64
+		for _, p := range smallPaths {
+			sum += p / small.CSubSampl
+		}
+		for _, p := range largePaths {
+			sum += p / large.CSubSampl
+		}
+		want := 0.5 - math.Pow(2, -sum/50)
+		if math.Abs(scores[i]-want) > 1e-12 {
+			t.Errorf("Merge() score = %v, want %v", scores[i], want)
+		}
+		if math.Abs(float64(scores32[i])-scores[i]) > 1e-5 {
+			t.Errorf("Forest.Float32() of the merged forest score = %v, want %v", scores32[i], scores[i])
+		}
+	}
+
+	// scales are persisted with the model
+	b, _ := json.Marshal(m)
+	loaded := &Forest{}
+	if err := json.Unmarshal(b, loaded); err != nil {
+		t.Fatalf("json.Unmarshal() error = %v", err)
+	}
+	_, loadedScores, _ := loaded.Predict(X)
+	for i := range X {
+		if loadedScores[i] != scores[i] {
+			t.Errorf("Forest.Predict() after loading = %v, want %v", loadedScores[i], scores[i])
+		}
+	}
+}
+
+func float32Rows(X [][]float64) [][]float32 {
+	X32 := make([][]float32, len(X))
+	for i := range X {
+		X32[i] = make([]float32, len(X[i]))
+		for j := range X[i] {
+			X32[i][j] = float32(X[i][j])
+		}
+	}
+	return X32
+}
+
+func TestForest_Split(t *testing.T) {
+	_, X := randomData32(200, 3)
+
+	f := NewForest(10, 64, 0.05)
+	if _, err := f.Split(2); err == nil {
+		t.Errorf("Forest.Split() should fail before training")
+	}
+	f.Seed = 9
+	f.Train(X)
+	f.Test(X)
+	_, scores, _ := f.Predict(X)
+	full, _ := f.AveragePathLength(X)
+
+	tests := []struct {
+		k         int
+		wantSizes []int
+		wantErr   bool
+	}{
+		{k: 0, wantErr: true},
+		{k: 11, wantErr: true},
+		{k: 1, wantSizes: []int{10}},
+		{k: 3, wantSizes: []int{4, 3, 3}},
+		{k: 10, wantSizes: []int{1, 1, 1, 1, 1, 1, 1, 1, 1, 1}},
+	}
+	for _, tt := range tests {
+
+		shards, err := f.Split(tt.k)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("Forest.Split(%d) error = %v, wantErr %v", tt.k, err, tt.wantErr)
+			continue
+		}
+		if err != nil {
+			continue
+		}
+		paths := make([][]float64, len(shards))
+		for s, g := range shards {
+			if len(g.Trees) != tt.wantSizes[s] || g.NbTrees != tt.wantSizes[s] {
+				t.Errorf("Forest.Split(%d) shard %d has %v trees, want %v", tt.k, s, len(g.Trees), tt.wantSizes[s])
+			}
+			paths[s], _ = g.AveragePathLength(X)
+		}
+
+		combined, err := CombinePathLengths(shards, paths)
+		if err != nil {
+			t.Fatalf("CombinePathLengths() error = %v", err)
+		}
+		m, err := Merge(shards...)
+		if err != nil {
+			t.Fatalf("Merge() of the shards error = %v", err)
+		}
+		merged, _ := m.AveragePathLength(X)
+		for i := range X {
+			if math.Abs(combined[i]-full[i]) > 1e-12 {
+				t.Errorf("CombinePathLengths() = %v, want %v", combined[i], full[i])
+			}
+			if m.PathScore(merged[i]) != scores[i] {
+				t.Errorf("Merge() of the shards score = %v, want %v", m.PathScore(merged[i]), scores[i])
+			}
+		}
+
+	}
+
+	// shards do not share the state of the forest
+	f.Schema, _ = NewSchema([]string{"a", "b", "c"}, X)
+	min := *f.Schema.Features[0].Min
+	shards, _ := f.Split(2)
+	*shards[0].Schema.Features[0].Min = min - 1
+	shards[0].ScoreSketch.Quantiles[0]--
+	shards[0].Calibration.Mean++
+	if *f.Schema.Features[0].Min != min || *shards[1].Schema.Features[0].Min != min ||
+		f.ScoreSketch.Quantiles[0] != shards[1].ScoreSketch.Quantiles[0] || f.Calibration.Mean != shards[1].Calibration.Mean {
+		t.Errorf("Forest.Split() shards share their state with the forest")
+	}
+
+	f.Aggregation = MedianAggregation
+	shards, _ = f.Split(2)
+	if _, err := CombinePathLengths(shards, [][]float64{full, full}); err == nil {
+		t.Errorf("CombinePathLengths() of median shards should fail")
+	}
+	if _, err := CombinePathLengths(shards, [][]float64{full}); err == nil {
+		t.Errorf("CombinePathLengths() with missing paths should fail")
+	}
+}
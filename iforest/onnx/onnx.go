@@ -0,0 +1,301 @@
+// Package onnx exports trained forests as ONNX models, to score vectors with
+// any ONNX runtime supporting the ai.onnx.ml operators.
+//
+// The trees are converted into a TreeEnsembleRegressor whose leaf values are
+// the path lengths depth + c(Size), so that it computes the aggregated path
+// length E[h(x)] of a vector. The graph then normalises it into an anomaly
+// score and compares the score with the anomaly bound of the forest:
+//
+//	X (float, [N, features]) -> score (float, [N, 1]), label (int64, [N, 1])
+//
+// ONNX runtimes score float32 vectors. As for Forest32, split values are
+// rounded up to the nearest float32 so vectors take the same paths as in the
+// forest, and scores differ from the float64 ones by a relative error of the
+// order of 1e-7. Calibrations are not exported.
+package onnx
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"google.golang.org/protobuf/proto"
+
+	"github.com/e-XpertSolutions/go-iforest/v2/iforest"
+	"github.com/e-XpertSolutions/go-iforest/v2/iforest/onnx/onnxpb"
+)
+
+// Names of the input and of the outputs of the exported graphs.
+const (
+	InputName      = "X"
+	PathLengthName = "path_length"
+	ScoreName      = "score"
+	LabelName      = "label"
+)
+
+// Versions of the operator sets used by the exported models.
+const (
+	IRVersion      = 7
+	OpsetVersion   = 13
+	MLOpsetVersion = 1
+)
+
+// MLDomain is the domain of the ONNX machine learning operators.
+const MLDomain = "ai.onnx.ml"
+
+// Export converts the forest into an ONNX model. The forest must have been
+// trained and tested and use the mean or the weighted mean aggregation.
+func Export(f *iforest.Forest) (*onnxpb.ModelProto, error) {
+	if !f.Trained {
+		return nil, errors.New("cannot export - model has not been trained yet")
+	}
+	if !f.Tested {
+		return nil, errors.New("cannot export - model has not been tested yet")
+	}
+	if len(f.Trees) == 0 {
+		return nil, errors.New("cannot export - model has no trees")
+	}
+
+	ensemble, err := treeEnsemble(f)
+	if err != nil {
+		return nil, err
+	}
+	nbFeatures := f.NbFeatures
+	if nbFeatures == 0 {
+		nbFeatures = maxAttribute(f) + 1
+	}
+
+	graph := &onnxpb.GraphProto{
+		Name: "iforest",
+		Node: []*onnxpb.NodeProto{
+			ensemble,
+			node("Mul", []string{PathLengthName, "neg_inv_c"}, "exponent"),
+			node("Pow", []string{"two", "exponent"}, "power"),
+			node("Sub", []string{"half", "power"}, ScoreName),
+			node("Less", []string{ScoreName, "anomaly_bound"}, "is_anomaly"),
+			node("Cast", []string{"is_anomaly"}, LabelName,
+				&onnxpb.AttributeProto{Name: "to", Type: onnxpb.AttributeProto_INT, I: int64(onnxpb.TensorProto_INT64)}),
+		},
+		Initializer: []*onnxpb.TensorProto{
+			scalar("neg_inv_c", -1/f.CSubSampl),
+			scalar("two", 2),
+			scalar("half", 0.5),
+			scalar("anomaly_bound", f.AnomalyBound),
+		},
+		Input: []*onnxpb.ValueInfoProto{
+			tensorInfo(InputName, onnxpb.TensorProto_FLOAT, int64(nbFeatures)),
+		},
+		Output: []*onnxpb.ValueInfoProto{
+			tensorInfo(ScoreName, onnxpb.TensorProto_FLOAT, 1),
+			tensorInfo(LabelName, onnxpb.TensorProto_INT64, 1),
+		},
+		ValueInfo: []*onnxpb.ValueInfoProto{
+			tensorInfo(PathLengthName, onnxpb.TensorProto_FLOAT, 1),
+		},
+	}
+
+	model := &onnxpb.ModelProto{
+		IrVersion: IRVersion,
+		OpsetImport: []*onnxpb.OperatorSetIdProto{
+			{Domain: "", Version: OpsetVersion},
+			{Domain: MLDomain, Version: MLOpsetVersion},
+		},
+		ProducerName: "go-iforest",
+		DocString:    "Isolation forest anomaly scores, anomalies are labelled 1.",
+		Graph:        graph,
+	}
+	if f.Schema != nil {
+		model.MetadataProps = append(model.MetadataProps, &onnxpb.StringStringEntryProto{
+			Key:   "features",
+			Value: strings.Join(f.Schema.Names(), ","),
+		})
+	}
+	return model, nil
+}
+
+// Save exports the forest and saves the ONNX model in the file.
+func Save(path string, f *iforest.Forest) error {
+	model, err := Export(f)
+	if err != nil {
+		return err
+	}
+	data, err := proto.Marshal(model)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0644)
+}
+
+// treeEnsemble converts the trees of the forest into a TreeEnsembleRegressor
+// node computing the aggregated path length. Nodes of each tree are numbered
+// in preorder, the true branch of a node being its left child.
+func treeEnsemble(f *iforest.Forest) (*onnxpb.NodeProto, error) {
+	aggregate := "AVERAGE"
+	weights := make([]float64, len(f.Trees))
+	for j := range weights {
+		weights[j] = 1
+	}
+	switch f.Aggregation {
+	case iforest.MeanAggregation:
+	case iforest.WeightedAggregation:
+		// leaf values are weighted so that their sum is the weighted mean
+		if len(f.TreeWeights) != len(f.Trees) {
+			return nil, errors.New("cannot export - there must be one weight per tree")
+		}
+		var total float64
+		for _, w := range f.TreeWeights {
+			total += w
+		}
+		if total <= 0 {
+			return nil, errors.New("cannot export - tree weights cannot all be 0")
+		}
+		for j, w := range f.TreeWeights {
+			weights[j] = w / total
+		}
+		aggregate = "SUM"
+	default:
+		return nil, fmt.Errorf("cannot export - %q aggregation is not supported", f.Aggregation)
+	}
+
+	var (
+		treeIDs, nodeIDs, featureIDs, trueIDs, falseIDs []int64
+		values                                          []float32
+		modes                                           [][]byte
+		targetTreeIDs, targetNodeIDs, targetIDs         []int64
+		targetWeights                                   []float32
+	)
+	for j, t := range f.Trees {
+		if t.Root == nil {
+			return nil, fmt.Errorf("cannot export - tree %d is empty", j)
+		}
+		scale := t.Scale
+		if scale == 0 {
+			scale = 1
+		}
+		scale *= weights[j]
+
+		var count int64
+		var add func(n *iforest.Node, depth int) int64
+		add = func(n *iforest.Node, depth int) int64 {
+			id := count
+			count++
+			k := len(nodeIDs)
+			treeIDs = append(treeIDs, int64(j))
+			nodeIDs = append(nodeIDs, id)
+			featureIDs = append(featureIDs, 0)
+			values = append(values, 0)
+			trueIDs = append(trueIDs, 0)
+			falseIDs = append(falseIDs, 0)
+
+			// as in iforest.PathLength, nodes holding at most one vector are
+			// leaves without adjustment
+			if n.External || (depth > 0 && n.Size <= 1) {
+				h := float64(depth)
+				if n.Size > 1 {
+					h += n.C
+				}
+				modes = append(modes, []byte("LEAF"))
+				targetTreeIDs = append(targetTreeIDs, int64(j))
+				targetNodeIDs = append(targetNodeIDs, id)
+				targetIDs = append(targetIDs, 0)
+				targetWeights = append(targetWeights, float32(h*scale))
+				return id
+			}
+			modes = append(modes, []byte("BRANCH_LT"))
+			featureIDs[k] = int64(n.Attribute)
+			values[k] = iforest.RoundUp32(n.Split)
+			trueIDs[k] = add(n.Left, depth+1)
+			falseIDs[k] = add(n.Right, depth+1)
+			return id
+		}
+		add(t.Root, 0)
+	}
+
+	return node("TreeEnsembleRegressor", []string{InputName}, PathLengthName,
+		ints("nodes_treeids", treeIDs),
+		ints("nodes_nodeids", nodeIDs),
+		ints("nodes_featureids", featureIDs),
+		floats("nodes_values", values),
+		strs("nodes_modes", modes),
+		ints("nodes_truenodeids", trueIDs),
+		ints("nodes_falsenodeids", falseIDs),
+		ints("target_treeids", targetTreeIDs),
+		ints("target_nodeids", targetNodeIDs),
+		ints("target_ids", targetIDs),
+		floats("target_weights", targetWeights),
+		&onnxpb.AttributeProto{Name: "n_targets", Type: onnxpb.AttributeProto_INT, I: 1},
+		&onnxpb.AttributeProto{Name: "aggregate_function", Type: onnxpb.AttributeProto_STRING, S: []byte(aggregate)},
+		&onnxpb.AttributeProto{Name: "post_transform", Type: onnxpb.AttributeProto_STRING, S: []byte("NONE")},
+	), nil
+}
+
+// maxAttribute returns the highest attribute used by the splits of the forest.
+func maxAttribute(f *iforest.Forest) int {
+	max := 0
+	var visit func(n *iforest.Node)
+	visit = func(n *iforest.Node) {
+		if n == nil || n.External {
+			return
+		}
+		if n.Attribute > max {
+			max = n.Attribute
+		}
+		visit(n.Left)
+		visit(n.Right)
+	}
+	for _, t := range f.Trees {
+		visit(t.Root)
+	}
+	return max
+}
+
+func node(opType string, inputs []string, output string, attributes ...*onnxpb.AttributeProto) *onnxpb.NodeProto {
+	n := &onnxpb.NodeProto{
+		Name:      output,
+		OpType:    opType,
+		Input:     inputs,
+		Output:    []string{output},
+		Attribute: attributes,
+	}
+	if opType == "TreeEnsembleRegressor" {
+		n.Domain = MLDomain
+	}
+	return n
+}
+
+func ints(name string, v []int64) *onnxpb.AttributeProto {
+	return &onnxpb.AttributeProto{Name: name, Type: onnxpb.AttributeProto_INTS, Ints: v}
+}
+
+func floats(name string, v []float32) *onnxpb.AttributeProto {
+	return &onnxpb.AttributeProto{Name: name, Type: onnxpb.AttributeProto_FLOATS, Floats: v}
+}
+
+func strs(name string, v [][]byte) *onnxpb.AttributeProto {
+	return &onnxpb.AttributeProto{Name: name, Type: onnxpb.AttributeProto_STRINGS, Strings: v}
+}
+
+// scalar returns a float32 scalar constant.
+func scalar(name string, v float64) *onnxpb.TensorProto {
+	return &onnxpb.TensorProto{
+		Name:      name,
+		DataType:  int32(onnxpb.TensorProto_FLOAT),
+		FloatData: []float32{float32(v)},
+	}
+}
+
+// tensorInfo describes a tensor of given type with a dynamic number of rows
+// and the given number of columns.
+func tensorInfo(name string, elemType onnxpb.TensorProto_DataType, columns int64) *onnxpb.ValueInfoProto {
+	return &onnxpb.ValueInfoProto{
+		Name: name,
+		Type: &onnxpb.TypeProto{Value: &onnxpb.TypeProto_TensorType{TensorType: &onnxpb.TypeProto_Tensor{
+			ElemType: int32(elemType),
+			Shape: &onnxpb.TensorShapeProto{Dim: []*onnxpb.TensorShapeProto_Dimension{
+				{Value: &onnxpb.TensorShapeProto_Dimension_DimParam{DimParam: "N"}},
+				{Value: &onnxpb.TensorShapeProto_Dimension_DimValue{DimValue: columns}},
+			}},
+		}}},
+	}
+}
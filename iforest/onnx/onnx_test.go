@@ -0,0 +1,284 @@
+package onnx
+
+import (
+	"math"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"google.golang.org/protobuf/proto"
+
+	"github.com/e-XpertSolutions/go-iforest/v2/iforest"
+	"github.com/e-XpertSolutions/go-iforest/v2/iforest/onnx/onnxpb"
+)
+
+// sample returns normal vectors with a few outliers, rounded to float32.
+func sample(n, nbFeatures int, seed int64) [][]float64 {
+	r := rand.New(rand.NewSource(seed))
+	X := make([][]float64, n)
+	for i := range X {
+		X[i] = make([]float64, nbFeatures)
+		for j := range X[i] {
+			X[i][j] = float64(float32(r.NormFloat64()))
+			if i%50 == 0 {
+				X[i][j] += 6
+			}
+		}
+	}
+	return X
+}
+
+func trainedForest(t *testing.T) *iforest.Forest {
+	X := sample(500, 3, 1)
+	f := iforest.NewForest(30, 128, 0.02)
+	f.Seed = 1
+	f.Train(X)
+	if err := f.Test(X); err != nil {
+		t.Fatal(err)
+	}
+	return f
+}
+
+// ensemble evaluates the TreeEnsembleRegressor of a model.
+type ensemble struct {
+	attributes map[string]*onnxpb.AttributeProto
+	// nodes maps tree and node ids to their index in the attributes.
+	nodes   map[[2]int64]int
+	targets map[[2]int64]int
+}
+
+func newEnsemble(t *testing.T, n *onnxpb.NodeProto) *ensemble {
+	e := &ensemble{
+		attributes: make(map[string]*onnxpb.AttributeProto),
+		nodes:      make(map[[2]int64]int),
+		targets:    make(map[[2]int64]int),
+	}
+	for _, a := range n.Attribute {
+		e.attributes[a.Name] = a
+	}
+	nbNodes := len(e.ints("nodes_nodeids"))
+	for _, name := range []string{"nodes_treeids", "nodes_featureids", "nodes_truenodeids", "nodes_falsenodeids"} {
+		if len(e.ints(name)) != nbNodes {
+			t.Fatalf("%s has %d values, want %d", name, len(e.ints(name)), nbNodes)
+		}
+	}
+	if len(e.attributes["nodes_values"].Floats) != nbNodes || len(e.attributes["nodes_modes"].Strings) != nbNodes {
+		t.Fatalf("nodes_values or nodes_modes do not have %d values", nbNodes)
+	}
+	for k, id := range e.ints("nodes_nodeids") {
+		e.nodes[[2]int64{e.ints("nodes_treeids")[k], id}] = k
+	}
+	for k, id := range e.ints("target_nodeids") {
+		e.targets[[2]int64{e.ints("target_treeids")[k], id}] = k
+	}
+	return e
+}
+
+func (e *ensemble) ints(name string) []int64 {
+	return e.attributes[name].Ints
+}
+
+// leaf returns the target weight of the leaf reached by x in the tree.
+func (e *ensemble) leaf(t *testing.T, tree int64, x []float32) float32 {
+	k := e.nodes[[2]int64{tree, 0}]
+	for string(e.attributes["nodes_modes"].Strings[k]) == "BRANCH_LT" {
+		next := e.ints("nodes_falsenodeids")[k]
+		if x[e.ints("nodes_featureids")[k]] < e.attributes["nodes_values"].Floats[k] {
+			next = e.ints("nodes_truenodeids")[k]
+		}
+		var ok bool
+		if k, ok = e.nodes[[2]int64{tree, next}]; !ok {
+			t.Fatalf("tree %d has no node %d", tree, next)
+		}
+	}
+	if mode := string(e.attributes["nodes_modes"].Strings[k]); mode != "LEAF" {
+		t.Fatalf("unexpected node mode %s", mode)
+	}
+	target, ok := e.targets[[2]int64{tree, e.ints("nodes_nodeids")[k]}]
+	if !ok {
+		t.Fatalf("leaf %d of tree %d has no target", e.ints("nodes_nodeids")[k], tree)
+	}
+	return e.attributes["target_weights"].Floats[target]
+}
+
+// graphNode returns the node computing given output.
+func graphNode(t *testing.T, g *onnxpb.GraphProto, output string) *onnxpb.NodeProto {
+	for _, n := range g.Node {
+		if len(n.Output) == 1 && n.Output[0] == output {
+			return n
+		}
+	}
+	t.Fatalf("no node computes %s", output)
+	return nil
+}
+
+func initializer(t *testing.T, g *onnxpb.GraphProto, name string) float32 {
+	for _, i := range g.Initializer {
+		if i.Name == name && len(i.FloatData) == 1 {
+			return i.FloatData[0]
+		}
+	}
+	t.Fatalf("no scalar initializer %s", name)
+	return 0
+}
+
+func TestExport(t *testing.T) {
+	f := trainedForest(t)
+	model, err := Export(f)
+	if err != nil {
+		t.Fatalf("Export() error = %v", err)
+	}
+	// the model is checked after a round trip through its wire format
+	data, err := proto.Marshal(model)
+	if err != nil {
+		t.Fatal(err)
+	}
+	model = &onnxpb.ModelProto{}
+	if err := proto.Unmarshal(data, model); err != nil {
+		t.Fatal(err)
+	}
+
+	opsets := make(map[string]int64)
+	for _, o := range model.OpsetImport {
+		opsets[o.Domain] = o.Version
+	}
+	if model.IrVersion != IRVersion || opsets[""] != OpsetVersion || opsets[MLDomain] != MLOpsetVersion {
+		t.Errorf("Export() ir version %d, opsets %v", model.IrVersion, opsets)
+	}
+	g := model.Graph
+	if len(g.Input) != 1 || g.Input[0].Name != InputName ||
+		g.Input[0].GetType().GetTensorType().GetShape().GetDim()[1].GetDimValue() != 3 {
+		t.Errorf("Export() input = %v, want %s with 3 features", g.Input, InputName)
+	}
+	if len(g.Output) != 2 || g.Output[0].Name != ScoreName || g.Output[1].Name != LabelName ||
+		g.Output[1].GetType().GetTensorType().GetElemType() != int32(onnxpb.TensorProto_INT64) {
+		t.Errorf("Export() outputs = %v, want %s and %s", g.Output, ScoreName, LabelName)
+	}
+	wantOps := map[string]string{
+		PathLengthName: "TreeEnsembleRegressor",
+		"exponent":     "Mul",
+		"power":        "Pow",
+		ScoreName:      "Sub",
+		"is_anomaly":   "Less",
+		LabelName:      "Cast",
+	}
+	for output, op := range wantOps {
+		if n := graphNode(t, g, output); n.OpType != op {
+			t.Errorf("Export() computes %s with %s, want %s", output, n.OpType, op)
+		}
+	}
+	regressor := graphNode(t, g, PathLengthName)
+	if regressor.Domain != MLDomain {
+		t.Errorf("Export() TreeEnsembleRegressor domain = %q", regressor.Domain)
+	}
+	e := newEnsemble(t, regressor)
+	if agg := string(e.attributes["aggregate_function"].S); agg != "AVERAGE" {
+		t.Errorf("Export() aggregate_function = %s, want AVERAGE", agg)
+	}
+
+	// leaf values are the path lengths, the graph computes the scores of
+	// the float32 forest
+	X := sample(200, 3, 2)
+	X32 := make([][]float32, len(X))
+	for i := range X {
+		X32[i] = []float32{float32(X[i][0]), float32(X[i][1]), float32(X[i][2])}
+	}
+	_, wantScores, err := f.Float32().Predict(X32)
+	if err != nil {
+		t.Fatal(err)
+	}
+	negInvC := initializer(t, g, "neg_inv_c")
+	bound := initializer(t, g, "anomaly_bound")
+	for i := range X {
+		paths, _ := f.PathLengths(X[i])
+		var sum float64
+		for j, want := range paths {
+			got := e.leaf(t, int64(j), X32[i])
+			if math.Abs(float64(got)-want) > 1e-5*want {
+				t.Fatalf("vector %d: leaf of tree %d = %v, want path length %v", i, j, got, want)
+			}
+			sum += float64(got)
+		}
+		score := float32(0.5 - math.Pow(2, sum/float64(len(paths))*float64(negInvC)))
+		if math.Abs(float64(score-wantScores[i])) > 1e-6 {
+			t.Errorf("vector %d: score = %v, want %v", i, score, wantScores[i])
+		}
+		if score < bound != (wantScores[i] < float32(f.AnomalyBound)) && math.Abs(float64(score-bound)) > 1e-6 {
+			t.Errorf("vector %d: score %v and bound %v give a different label", i, score, bound)
+		}
+	}
+}
+
+func TestExport_weighted(t *testing.T) {
+	f := trainedForest(t)
+	if err := f.LearnTreeWeights(sample(200, 3, 3)); err != nil {
+		t.Fatal(err)
+	}
+	model, err := Export(f)
+	if err != nil {
+		t.Fatalf("Export() error = %v", err)
+	}
+	e := newEnsemble(t, graphNode(t, model.Graph, PathLengthName))
+	if agg := string(e.attributes["aggregate_function"].S); agg != "SUM" {
+		t.Errorf("Export() aggregate_function = %s, want SUM", agg)
+	}
+	X := sample(50, 3, 4)
+	want, _ := f.AveragePathLength(X)
+	for i := range X {
+		x := []float32{float32(X[i][0]), float32(X[i][1]), float32(X[i][2])}
+		var sum float64
+		for j := range f.Trees {
+			sum += float64(e.leaf(t, int64(j), x))
+		}
+		if math.Abs(sum-want[i]) > 1e-5*want[i] {
+			t.Errorf("vector %d: weighted path length = %v, want %v", i, sum, want[i])
+		}
+	}
+}
+
+func TestExport_errors(t *testing.T) {
+	untested := iforest.NewForest(10, 32, 0.1)
+	untested.Train(sample(100, 2, 1))
+	median := trainedForest(t)
+	median.Aggregation = iforest.MedianAggregation
+	tests := []struct {
+		name string
+		f    *iforest.Forest
+	}{
+		{name: "untrained", f: iforest.NewForest(10, 32, 0.1)},
+		{name: "untested", f: untested},
+		{name: "median", f: median},
+	}
+	for _, tt := range tests {
+
+		if _, err := Export(tt.f); err == nil {
+			t.Errorf("%s: Export() error = nil, want an error", tt.name)
+		}
+
+	}
+}
+
+func TestSave(t *testing.T) {
+	f := trainedForest(t)
+	f.Schema = &iforest.Schema{Features: []iforest.Feature{{Name: "a"}, {Name: "b"}, {Name: "c"}}}
+	path := filepath.Join(t.TempDir(), "model.onnx")
+	if err := Save(path, f); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	saved := &onnxpb.ModelProto{}
+	if err := proto.Unmarshal(data, saved); err != nil {
+		t.Fatal(err)
+	}
+	want, _ := Export(f)
+	if !proto.Equal(saved, want) {
+		t.Errorf("Save() saved a different model")
+	}
+	if len(saved.MetadataProps) != 1 || saved.MetadataProps[0].Value != "a,b,c" {
+		t.Errorf("Save() metadata = %v, want the feature names", saved.MetadataProps)
+	}
+}
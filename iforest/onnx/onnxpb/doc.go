@@ -0,0 +1,5 @@
+// Package onnxpb holds the subset of the ONNX protobuf messages used to export
+// forests and the code generated from it.
+package onnxpb
+
+//go:generate protoc --go_out=. --go_opt=paths=source_relative onnx.proto
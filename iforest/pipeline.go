@@ -0,0 +1,387 @@
+package iforest
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"sort"
+)
+
+// StepKind identifies the transformation of a pipeline step.
+type StepKind string
+
+// Step kinds.
+const (
+	// StandardScalerStep centers the values on their mean and divides them
+	// by their standard deviation.
+	StandardScalerStep StepKind = "standard"
+	// MinMaxScalerStep maps the values of the fitting data to [0, 1].
+	MinMaxScalerStep StepKind = "minmax"
+	// Log1pStep replaces the values by log(1+x). Values below -1 are errors.
+	Log1pStep StepKind = "log1p"
+	// ImputeStep replaces missing values, NaN, by the mean or the median of
+	// the fitting data or by a constant.
+	ImputeStep StepKind = "impute"
+	// OneHotStep replaces a categorical column by one indicator column per
+	// category of the fitting data, in increasing order of the categories.
+	// Unknown categories and missing values get no indicator.
+	OneHotStep StepKind = "onehot"
+)
+
+// ImputeStrategy chooses the values replacing missing values.
+type ImputeStrategy string
+
+// Imputation strategies.
+const (
+	ImputeMean     ImputeStrategy = "mean"
+	ImputeMedian   ImputeStrategy = "median"
+	ImputeConstant ImputeStrategy = "constant"
+)
+
+// Step is a transformation of the vectors of a pipeline. Its parameters are
+// learned by Pipeline.Fit.
+type Step struct {
+	Kind StepKind
+	// Columns are the indices of the transformed columns in the vectors
+	// given to the step. All columns are transformed when empty, Fit then
+	// lists them.
+	Columns []int
+
+	// Strategy and Value configure the imputation.
+	Strategy ImputeStrategy `json:",omitempty"`
+	Value    float64        `json:",omitempty"`
+
+	// Center and Scale are the learned parameters of the scalers, values
+	// become (x-Center)/Scale. Fill holds the imputed values and Categories
+	// the categories of the one-hot encoding, one per column.
+	Center     []float64   `json:",omitempty"`
+	Scale      []float64   `json:",omitempty"`
+	Fill       []float64   `json:",omitempty"`
+	Categories [][]float64 `json:",omitempty"`
+}
+
+// StandardScaler returns a standard scaler step for given columns, all
+// columns if none.
+func StandardScaler(columns ...int) Step {
+	return Step{Kind: StandardScalerStep, Columns: columns}
+}
+
+// MinMaxScaler returns a min-max scaler step for given columns, all columns
+// if none.
+func MinMaxScaler(columns ...int) Step {
+	return Step{Kind: MinMaxScalerStep, Columns: columns}
+}
+
+// Log1p returns a log1p step for given columns, all columns if none.
+func Log1p(columns ...int) Step {
+	return Step{Kind: Log1pStep, Columns: columns}
+}
+
+// Impute returns an imputation step for given columns, all columns if none.
+// The value is only used by the constant strategy.
+func Impute(strategy ImputeStrategy, value float64, columns ...int) Step {
+	return Step{Kind: ImputeStep, Strategy: strategy, Value: value, Columns: columns}
+}
+
+// OneHot returns a one-hot encoding step for given columns, all columns if
+// none.
+func OneHot(columns ...int) Step {
+	return Step{Kind: OneHotStep, Columns: columns}
+}
+
+// Pipeline chains transformations of the vectors with a forest. It is fitted
+// as a unit and saved with its forest, so that vectors are transformed the
+// same way for training and prediction.
+//
+// Isolation forests are insensitive to the scale of each feature, scalers
+// mostly make the vectors given to the trees comparable with the ones of
+// other tools.
+type Pipeline struct {
+	Steps  []Step
+	Forest *Forest
+	// NbFeatures is the number of attributes of the raw vectors.
+	NbFeatures int
+	Fitted     bool
+}
+
+// NewPipeline initializes a pipeline transforming vectors with given steps
+// before the forest.
+func NewPipeline(forest *Forest, steps ...Step) *Pipeline {
+	return &Pipeline{Steps: steps, Forest: forest}
+}
+
+// Fit learns the parameters of the steps on the dataset, then trains and
+// tests the forest on the transformed dataset.
+func (p *Pipeline) Fit(X [][]float64) error {
+	if p.Forest == nil {
+		return errors.New("cannot fit - pipeline has no forest")
+	}
+	if len(X) == 0 {
+		return errors.New("cannot fit - no data")
+	}
+	p.Fitted = false
+	p.NbFeatures = len(X[0])
+	if err := checkWidth(X, p.NbFeatures); err != nil {
+		return fmt.Errorf("cannot fit - %v", err)
+	}
+
+	for k := range p.Steps {
+		s := &p.Steps[k]
+		if err := s.fit(X); err != nil {
+			return fmt.Errorf("cannot fit step %d (%s) - %v", k, s.Kind, err)
+		}
+		var err error
+		if X, err = s.transform(X); err != nil {
+			return fmt.Errorf("cannot fit step %d (%s) - %v", k, s.Kind, err)
+		}
+	}
+	if err := checkMissing(X); err != nil {
+		return fmt.Errorf("cannot fit - %v", err)
+	}
+
+	p.Forest.Train(X)
+	if err := p.Forest.Test(X); err != nil {
+		return err
+	}
+	p.Fitted = true
+	return nil
+}
+
+// Transform applies the steps to the vectors, which are not modified.
+func (p *Pipeline) Transform(X [][]float64) ([][]float64, error) {
+	if !p.Fitted {
+		return nil, errors.New("cannot transform - pipeline has not been fitted yet")
+	}
+	if err := checkWidth(X, p.NbFeatures); err != nil {
+		return nil, err
+	}
+	for k := range p.Steps {
+		var err error
+		if X, err = p.Steps[k].transform(X); err != nil {
+			return nil, fmt.Errorf("step %d (%s): %v", k, p.Steps[k].Kind, err)
+		}
+	}
+	return X, nil
+}
+
+// Predict transforms the raw vectors and labels them with the forest.
+func (p *Pipeline) Predict(X [][]float64) ([]int, []float64, error) {
+	X, err := p.Transform(X)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := checkMissing(X); err != nil {
+		return nil, nil, err
+	}
+	return p.Forest.Predict(X)
+}
+
+// Save saves the pipeline and its forest in the file.
+func (p *Pipeline) Save(path string) error {
+	file, err := os.Create(path)
+	if err == nil {
+		encoder := json.NewEncoder(file)
+		err = encoder.Encode(p)
+	}
+	file.Close()
+	return err
+}
+
+// Load loads the pipeline and its forest from the file.
+func (p *Pipeline) Load(path string) error {
+	file, err := os.Open(path)
+	if err == nil {
+		p.Forest = &Forest{AnomalyScores: make(map[int]float64)}
+		decoder := json.NewDecoder(file)
+		err = decoder.Decode(p)
+	}
+	file.Close()
+	return err
+}
+
+// checkWidth verifies that all vectors have n attributes.
+func checkWidth(X [][]float64, n int) error {
+	for i := range X {
+		if len(X[i]) != n {
+			return fmt.Errorf("vector %d has %d attributes, want %d", i, len(X[i]), n)
+		}
+	}
+	return nil
+}
+
+// checkMissing verifies that no value is missing.
+func checkMissing(X [][]float64) error {
+	for i := range X {
+		for j, v := range X[i] {
+			if math.IsNaN(v) {
+				return fmt.Errorf("vector %d has a missing value in column %d, it must be imputed", i, j)
+			}
+		}
+	}
+	return nil
+}
+
+// fit learns the parameters of the step on its input vectors.
+func (s *Step) fit(X [][]float64) error {
+	width := len(X[0])
+	if len(s.Columns) == 0 {
+		s.Columns = make([]int, width)
+		for c := range s.Columns {
+			s.Columns[c] = c
+		}
+	}
+	seen := make(map[int]bool, len(s.Columns))
+	for _, j := range s.Columns {
+		if j < 0 || j >= width || seen[j] {
+			return fmt.Errorf("invalid column %d", j)
+		}
+		seen[j] = true
+	}
+	s.Center, s.Scale, s.Fill, s.Categories = nil, nil, nil, nil
+
+	switch s.Kind {
+	case StandardScalerStep, MinMaxScalerStep:
+		for _, j := range s.Columns {
+			values := columnValues(X, j)
+			if len(values) == 0 {
+				return fmt.Errorf("column %d has no value", j)
+			}
+			var center, scale float64
+			if s.Kind == StandardScalerStep {
+				for _, v := range values {
+					center += v
+				}
+				center /= float64(len(values))
+				for _, v := range values {
+					scale += (v - center) * (v - center)
+				}
+				scale = math.Sqrt(scale / float64(len(values)))
+			} else {
+				center, scale = values[0], values[len(values)-1]-values[0]
+			}
+			if scale == 0 {
+				scale = 1
+			}
+			s.Center = append(s.Center, center)
+			s.Scale = append(s.Scale, scale)
+		}
+	case Log1pStep:
+	case ImputeStep:
+		for _, j := range s.Columns {
+			fill := s.Value
+			if s.Strategy != ImputeConstant {
+				values := columnValues(X, j)
+				if len(values) == 0 {
+					return fmt.Errorf("column %d has no value", j)
+				}
+				switch s.Strategy {
+				case "", ImputeMean:
+					fill = 0
+					for _, v := range values {
+						fill += v
+					}
+					fill /= float64(len(values))
+				case ImputeMedian:
+					n := len(values)
+					fill = (values[(n-1)/2] + values[n/2]) / 2
+				default:
+					return fmt.Errorf("unknown strategy %q", s.Strategy)
+				}
+			}
+			s.Fill = append(s.Fill, fill)
+		}
+	case OneHotStep:
+		for _, j := range s.Columns {
+			values := columnValues(X, j)
+			var categories []float64
+			for i, v := range values {
+				if i == 0 || v != values[i-1] {
+					categories = append(categories, v)
+				}
+			}
+			if len(categories) == 0 {
+				return fmt.Errorf("column %d has no value", j)
+			}
+			s.Categories = append(s.Categories, categories)
+		}
+	default:
+		return errors.New("unknown step")
+	}
+	return nil
+}
+
+// columnValues returns the sorted values of a column, without the missing
+// ones.
+func columnValues(X [][]float64, j int) []float64 {
+	values := make([]float64, 0, len(X))
+	for i := range X {
+		if !math.IsNaN(X[i][j]) {
+			values = append(values, X[i][j])
+		}
+	}
+	sort.Float64s(values)
+	return values
+}
+
+// transform returns the transformed vectors.
+func (s *Step) transform(X [][]float64) ([][]float64, error) {
+	switch s.Kind {
+	case OneHotStep:
+		return s.oneHot(X), nil
+	case StandardScalerStep, MinMaxScalerStep, Log1pStep, ImputeStep:
+	default:
+		return nil, errors.New("unknown step")
+	}
+	Y := make([][]float64, len(X))
+	for i := range X {
+		y := append([]float64(nil), X[i]...)
+		for c, j := range s.Columns {
+			if j >= len(y) {
+				return nil, fmt.Errorf("vector %d has no column %d", i, j)
+			}
+			switch s.Kind {
+			case StandardScalerStep, MinMaxScalerStep:
+				y[j] = (y[j] - s.Center[c]) / s.Scale[c]
+			case Log1pStep:
+				if y[j] < -1 {
+					return nil, fmt.Errorf("vector %d has a value below -1 in column %d", i, j)
+				}
+				y[j] = math.Log1p(y[j])
+			case ImputeStep:
+				if math.IsNaN(y[j]) {
+					y[j] = s.Fill[c]
+				}
+			}
+		}
+		Y[i] = y
+	}
+	return Y, nil
+}
+
+// oneHot replaces the encoded columns by their indicator columns.
+func (s *Step) oneHot(X [][]float64) [][]float64 {
+	encoded := make(map[int][]float64, len(s.Columns))
+	for c, j := range s.Columns {
+		encoded[j] = s.Categories[c]
+	}
+	Y := make([][]float64, len(X))
+	for i := range X {
+		var y []float64
+		for j, v := range X[i] {
+			categories, ok := encoded[j]
+			if !ok {
+				y = append(y, v)
+				continue
+			}
+			indicators := make([]float64, len(categories))
+			if k := sort.SearchFloat64s(categories, v); k < len(categories) && categories[k] == v {
+				indicators[k] = 1
+			}
+			y = append(y, indicators...)
+		}
+		Y[i] = y
+	}
+	return Y
+}
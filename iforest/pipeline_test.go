@@ -0,0 +1,165 @@
+package iforest
+
+import (
+	"math"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestStep_Transform(t *testing.T) {
+	nan := math.NaN()
+	X := [][]float64{{1, 10, 2}, {3, nan, 0}, {5, 40, 2}, {nan, 20, 7}}
+	tests := []struct {
+		name    string
+		step    Step
+		want    [][]float64
+		wantErr bool
+	}{
+		{
+			name: "standard scaler",
+			step: StandardScaler(0),
+			want: [][]float64{{-math.Sqrt(1.5), 10, 2}, {0, nan, 0}, {math.Sqrt(1.5), 40, 2}, {nan, 20, 7}},
+		},
+		{
+			name: "min-max scaler",
+			step: MinMaxScaler(),
+			want: [][]float64{{0, 0, 2.0 / 7}, {0.5, nan, 0}, {1, 1, 2.0 / 7}, {nan, 1.0 / 3, 1}},
+		},
+		{
+			name: "log1p",
+			step: Log1p(2),
+			want: [][]float64{{1, 10, math.Log1p(2)}, {3, nan, 0}, {5, 40, math.Log1p(2)}, {nan, 20, math.Log(8)}},
+		},
+		{
+			name: "mean imputation",
+			step: Impute(ImputeMean, 0),
+			want: [][]float64{{1, 10, 2}, {3, 70.0 / 3, 0}, {5, 40, 2}, {3, 20, 7}},
+		},
+		{
+			name: "median imputation",
+			step: Impute(ImputeMedian, 0, 1),
+			want: [][]float64{{1, 10, 2}, {3, 20, 0}, {5, 40, 2}, {nan, 20, 7}},
+		},
+		{
+			name: "constant imputation",
+			step: Impute(ImputeConstant, -1, 0, 1),
+			want: [][]float64{{1, 10, 2}, {3, -1, 0}, {5, 40, 2}, {-1, 20, 7}},
+		},
+		{
+			name: "one-hot",
+			step: OneHot(2),
+			want: [][]float64{{1, 10, 0, 1, 0}, {3, nan, 1, 0, 0}, {5, 40, 0, 1, 0}, {nan, 20, 0, 0, 1}},
+		},
+		{name: "invalid column", step: StandardScaler(3), wantErr: true},
+		{name: "duplicate column", step: MinMaxScaler(1, 1), wantErr: true},
+		{name: "unknown strategy", step: Impute("mode", 0), wantErr: true},
+		{name: "unknown step", step: Step{Kind: "pca"}, wantErr: true},
+	}
+	for _, tt := range tests {
+
+		s := tt.step
+		err := s.fit(X)
+		var got [][]float64
+		if err == nil {
+			got, err = s.transform(X)
+		}
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: Step.fit() and transform() error = %v, wantErr %v", tt.name, err, tt.wantErr)
+			continue
+		}
+		if err == nil && !approxRows(got, tt.want) {
+			t.Errorf("%s: Step.transform() = %v, want %v", tt.name, got, tt.want)
+		}
+
+	}
+
+	if !math.IsNaN(X[1][1]) || X[0][0] != 1 {
+		t.Errorf("Step.transform() modified its input: %v", X)
+	}
+	s := Log1p(0)
+	if _, err := s.transform([][]float64{{-2}}); err == nil {
+		t.Errorf("Step.transform() of a value below -1 error = nil, want an error")
+	}
+}
+
+// approxRows compares rows up to rounding errors, NaN values being equal.
+func approxRows(a, b [][]float64) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if len(a[i]) != len(b[i]) {
+			return false
+		}
+		for j := range a[i] {
+			if math.IsNaN(a[i][j]) != math.IsNaN(b[i][j]) || math.Abs(a[i][j]-b[i][j]) > 1e-12 {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func TestPipeline(t *testing.T) {
+	_, X := randomData32(400, 3)
+	// the last column is a category and some values are missing
+	for i := range X {
+		X[i][2] = float64(i % 3)
+		if i%7 == 0 {
+			X[i][1] = math.NaN()
+		}
+	}
+	f := NewForest(50, 64, 0.02)
+	f.Seed = 1
+	p := NewPipeline(f, Impute(ImputeMedian, 0, 1), Log1p(0), StandardScaler(0, 1), OneHot(2))
+	if _, _, err := p.Predict(X); err == nil {
+		t.Errorf("Pipeline.Predict() before fitting error = nil, want an error")
+	}
+
+	// log1p of values below -1
+	if err := p.Fit(X); err == nil {
+		t.Fatalf("Pipeline.Fit() error = nil, want an error")
+	}
+	p.Steps[1] = MinMaxScaler(0)
+	if err := p.Fit(X); err != nil {
+		t.Fatalf("Pipeline.Fit() error = %v", err)
+	}
+	if p.NbFeatures != 3 || f.NbFeatures != 5 || !f.Trained || !f.Tested {
+		t.Errorf("Pipeline.Fit() = %v raw features, forest with %v features", p.NbFeatures, f.NbFeatures)
+	}
+
+	labels, scores, err := p.Predict(X)
+	if err != nil {
+		t.Fatalf("Pipeline.Predict() error = %v", err)
+	}
+	Y, err := p.Transform(X)
+	if err != nil {
+		t.Fatalf("Pipeline.Transform() error = %v", err)
+	}
+	wantLabels, wantScores, _ := f.Predict(Y)
+	if !reflect.DeepEqual(labels, wantLabels) || !reflect.DeepEqual(scores, wantScores) {
+		t.Errorf("Pipeline.Predict() differs from Forest.Predict() of the transformed data")
+	}
+
+	// the steps are saved with the forest
+	path := filepath.Join(t.TempDir(), "pipeline.json")
+	if err := p.Save(path); err != nil {
+		t.Fatal(err)
+	}
+	loaded := &Pipeline{}
+	if err := loaded.Load(path); err != nil {
+		t.Fatal(err)
+	}
+	loadedLabels, loadedScores, err := loaded.Predict(X)
+	if err != nil || !reflect.DeepEqual(loadedLabels, labels) || !reflect.DeepEqual(loadedScores, scores) {
+		t.Errorf("Pipeline.Predict() after loading differs, error = %v", err)
+	}
+
+	if _, _, err := loaded.Predict([][]float64{{1, 2}}); err == nil {
+		t.Errorf("Pipeline.Predict() with missing attributes error = nil, want an error")
+	}
+	if err := NewPipeline(NewForest(10, 32, 0.1), StandardScaler()).Fit(X); err == nil {
+		t.Errorf("Pipeline.Fit() with missing values error = nil, want an error")
+	}
+}
@@ -0,0 +1,161 @@
+package iforest
+
+import (
+	"errors"
+	"math"
+
+	"github.com/e-XpertSolutions/go-iforest/v2/iforest/eval"
+)
+
+// PruneOptions controls the selection of trees done by Prune. At least one of
+// NbTrees and Target must be set, the selection stops as soon as one of them
+// is reached.
+type PruneOptions struct {
+	// NbTrees is the maximum number of trees kept.
+	NbTrees int
+	// Target is the rank correlation with the scores of the whole forest from
+	// which the selection stops.
+	Target float64
+}
+
+// Fidelity compares the scores of a pruned forest with the ones of the forest
+// it was selected from, on the validation dataset.
+type Fidelity struct {
+	// RankCorrelation is the Spearman's rank correlation between the scores,
+	// 1 - RankCorrelation is the fidelity loss.
+	RankCorrelation float64
+	// MeanScoreError and MaxScoreError are the mean and the maximum absolute
+	// differences between the scores.
+	MeanScoreError float64
+	MaxScoreError  float64
+}
+
+// Prune returns a smaller forest made of the trees which best preserve the
+// ranking of the scores on given validation dataset. Trees are selected
+// greedily: each step adds the tree whose mean path lengths, together with
+// the already selected trees, correlate best with the path lengths of the
+// whole forest. Aggregation parameters, the anomaly bound and the calibration
+// are kept, so the pruned forest predicts right away. It can be tested again
+// to fit them on its own scores, the source forest being left unchanged.
+func (f *Forest) Prune(X [][]float64, opts PruneOptions) (*Forest, *Fidelity, error) {
+	if !f.Trained {
+		return nil, nil, errors.New("cannot prune - model has not been trained yet")
+	}
+	if err := f.checkAggregation(); err != nil {
+		return nil, nil, err
+	}
+	if len(X) < 2 {
+		return nil, nil, errors.New("cannot prune - not enough data")
+	}
+	if opts.NbTrees <= 0 && opts.Target <= 0 {
+		return nil, nil, errors.New("cannot prune - number of trees or target must be set")
+	}
+	if opts.Target > 1 {
+		return nil, nil, errors.New("cannot prune - target must not be above 1")
+	}
+	nbTrees := opts.NbTrees
+	if nbTrees <= 0 || nbTrees > len(f.Trees) {
+		nbTrees = len(f.Trees)
+	}
+
+	consensus := make([]float64, len(X))
+	paths := make([][]float64, len(f.Trees))
+	for j := range paths {
+		paths[j] = make([]float64, len(X))
+	}
+	for i := range X {
+		consensus[i] = f.averagePath(RowSlices(X), i)
+		for j := range f.Trees {
+			paths[j][i] = f.Trees[j].pathLength(RowSlices(X), i)
+		}
+	}
+	weights := make([]float64, len(f.Trees))
+	for j := range weights {
+		weights[j] = 1
+		if f.Aggregation == WeightedAggregation {
+			weights[j] = f.TreeWeights[j]
+		}
+	}
+
+	// running weighted sums of the path lengths of the selected trees
+	sums := make([]float64, len(X))
+	mean := make([]float64, len(X))
+	var total float64
+	selected := make([]bool, len(f.Trees))
+	var ids []int
+	for len(ids) < nbTrees {
+		best, bestRho := -1, math.Inf(-1)
+		for j := range f.Trees {
+			if selected[j] || weights[j] == 0 {
+				continue
+			}
+			for i := range X {
+				mean[i] = (sums[i] + weights[j]*paths[j][i]) / (total + weights[j])
+			}
+			rho, err := eval.Spearman(mean, consensus)
+			if err != nil {
+				// constant path lengths cannot be ranked
+				rho = 0
+			}
+			if rho > bestRho {
+				best, bestRho = j, rho
+			}
+		}
+		if best < 0 {
+			break
+		}
+		selected[best] = true
+		ids = append(ids, best)
+		for i := range X {
+			sums[i] += weights[best] * paths[best][i]
+		}
+		total += weights[best]
+		if opts.Target > 0 && bestRho >= opts.Target {
+			break
+		}
+	}
+
+	g := *f
+	g.Trees = make([]Tree, len(ids))
+	g.NbTrees = len(ids)
+	g.AnomalyScores = make(map[int]float64)
+	g.Labels = nil
+	f.cloneShared(&g)
+	if f.TreeWeights != nil {
+		g.TreeWeights = make([]float64, len(ids))
+	}
+	for k, j := range ids {
+		g.Trees[k] = f.Trees[j]
+		if f.TreeWeights != nil {
+			g.TreeWeights[k] = f.TreeWeights[j]
+		}
+	}
+
+	fidelity := &Fidelity{}
+	pruned := make([]float64, len(X))
+	for i := range X {
+		pruned[i] = g.averagePath(RowSlices(X), i)
+		diff := math.Abs(g.PathScore(pruned[i]) - f.PathScore(consensus[i]))
+		fidelity.MeanScoreError += diff
+		fidelity.MaxScoreError = math.Max(fidelity.MaxScoreError, diff)
+	}
+	fidelity.MeanScoreError /= float64(len(X))
+	rho, err := eval.Spearman(pruned, consensus)
+	if err != nil {
+		return nil, nil, err
+	}
+	fidelity.RankCorrelation = rho
+	return &g, fidelity, nil
+}
+
+// cloneShared gives g, a copy of f, its own sketch, calibration, schema and
+// tuned bound, which the copy shares with f otherwise.
+func (f *Forest) cloneShared(g *Forest) {
+	g.ScoreSketch = f.ScoreSketch.clone()
+	g.Calibration = f.Calibration.clone()
+	g.Schema = f.Schema.clone()
+	if f.TunedBound != nil {
+		bound := *f.TunedBound
+		g.TunedBound = &bound
+	}
+}
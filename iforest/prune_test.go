@@ -0,0 +1,107 @@
+package iforest
+
+import (
+	"math"
+	"reflect"
+	"testing"
+
+	"github.com/e-XpertSolutions/go-iforest/v2/iforest/eval"
+)
+
+func TestForest_Prune(t *testing.T) {
+	_, X := randomData32(300, 3)
+
+	f := NewForest(100, 64, 0.05)
+	if _, _, err := f.Prune(X, PruneOptions{NbTrees: 10}); err == nil {
+		t.Errorf("Forest.Prune() should fail before training")
+	}
+	f.Seed = 5
+	f.Train(X)
+	f.Test(X)
+	f.Schema, _ = NewSchema([]string{"a", "b", "c"}, X)
+	sketch, calibration := *f.ScoreSketch, *f.Calibration
+	sketch.Quantiles = append([]float64(nil), sketch.Quantiles...)
+	min := *f.Schema.Features[0].Min
+
+	tests := []struct {
+		name      string
+		opts      PruneOptions
+		wantTrees int
+		wantErr   bool
+	}{
+		{name: "no limit", opts: PruneOptions{}, wantErr: true},
+		{name: "target above 1", opts: PruneOptions{Target: 1.5}, wantErr: true},
+		{name: "ten trees", opts: PruneOptions{NbTrees: 10}, wantTrees: 10},
+		{name: "too many trees", opts: PruneOptions{NbTrees: 1000}, wantTrees: 100},
+		{name: "target", opts: PruneOptions{Target: 0.95}},
+		{name: "target and trees", opts: PruneOptions{NbTrees: 3, Target: 0.999}, wantTrees: 3},
+	}
+	for _, tt := range tests {
+
+		g, fidelity, err := f.Prune(X, tt.opts)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: Forest.Prune() error = %v, wantErr %v", tt.name, err, tt.wantErr)
+			continue
+		}
+		if err != nil {
+			continue
+		}
+		if tt.wantTrees > 0 && (len(g.Trees) != tt.wantTrees || g.NbTrees != tt.wantTrees) {
+			t.Errorf("%s: Forest.Prune() kept %v trees, want %v", tt.name, len(g.Trees), tt.wantTrees)
+		}
+		if tt.opts.Target > 0 && len(g.Trees) < len(f.Trees) && len(g.Trees) != tt.wantTrees && fidelity.RankCorrelation < tt.opts.Target {
+			t.Errorf("%s: Forest.Prune() correlation = %v, want at least %v", tt.name, fidelity.RankCorrelation, tt.opts.Target)
+		}
+
+		averages, _ := g.AveragePathLength(X)
+		full, _ := f.AveragePathLength(X)
+		var sum, max float64
+		for i := range X {
+			diff := math.Abs(g.PathScore(averages[i]) - f.PathScore(full[i]))
+			sum += diff
+			max = math.Max(max, diff)
+		}
+		if math.Abs(sum/float64(len(X))-fidelity.MeanScoreError) > 1e-12 || max != fidelity.MaxScoreError {
+			t.Errorf("%s: Forest.Prune() errors = %v and %v, want %v and %v", tt.name, fidelity.MeanScoreError, fidelity.MaxScoreError, sum/float64(len(X)), max)
+		}
+		if _, _, err := g.Predict(X); err != nil || g.AnomalyBound != f.AnomalyBound {
+			t.Errorf("%s: Forest.Predict() on the pruned forest error = %v, bound %v, want %v", tt.name, err, g.AnomalyBound, f.AnomalyBound)
+		}
+		if err := g.Test(X); err != nil {
+			t.Errorf("%s: Forest.Test() on the pruned forest error = %v", tt.name, err)
+		}
+
+	}
+	if !reflect.DeepEqual(*f.ScoreSketch, sketch) || !reflect.DeepEqual(*f.Calibration, calibration) {
+		t.Errorf("Forest.Test() on the pruned forest changed the source forest")
+	}
+	g, fidelity, _ := f.Prune(X, PruneOptions{NbTrees: 10})
+	*g.Schema.Features[0].Min = min - 1
+	if *f.Schema.Features[0].Min != min {
+		t.Errorf("Forest.Prune() shares the schema with the source forest")
+	}
+
+	// the greedy selection should beat the first trees of the forest
+	g, fidelity, _ = f.Prune(X, PruneOptions{NbTrees: 10})
+	first := *f
+	first.Trees = f.Trees[:10]
+	firstPaths, _ := first.AveragePathLength(X)
+	prunedPaths, _ := g.AveragePathLength(X)
+	full, _ := f.AveragePathLength(X)
+	firstRho, _ := eval.Spearman(firstPaths, full)
+	prunedRho, _ := eval.Spearman(prunedPaths, full)
+	if fidelity.RankCorrelation != prunedRho || fidelity.RankCorrelation < firstRho {
+		t.Errorf("Forest.Prune() correlation = %v, pruned paths give %v and first trees %v", fidelity.RankCorrelation, prunedRho, firstRho)
+	}
+
+	f.Aggregation = WeightedAggregation
+	f.TreeWeights = make([]float64, len(f.Trees))
+	f.TreeWeights[3], f.TreeWeights[7] = 1, 2
+	g, _, err := f.Prune(X, PruneOptions{NbTrees: 10})
+	if err != nil {
+		t.Fatalf("Forest.Prune() with weights error = %v", err)
+	}
+	if len(g.Trees) != 2 || len(g.TreeWeights) != 2 || g.TreeWeights[0]+g.TreeWeights[1] != 3 {
+		t.Errorf("Forest.Prune() with weights kept %v trees weighted %v", len(g.Trees), g.TreeWeights)
+	}
+}
@@ -0,0 +1,224 @@
+package iforest
+
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
+// FeatureType is the type of the values of a feature.
+type FeatureType string
+
+// Feature types.
+const (
+	// NumericFeature values are any real number. It is the default type.
+	NumericFeature FeatureType = "numeric"
+	// IntegerFeature values are integers.
+	IntegerFeature FeatureType = "integer"
+	// BooleanFeature values are 0 or 1.
+	BooleanFeature FeatureType = "boolean"
+)
+
+// Feature describes an attribute of the vectors.
+type Feature struct {
+	Name string
+	Type FeatureType `json:",omitempty"`
+	// Min and Max are the expected range of the values, if known. Values out
+	// of the range are still scored, they are often the anomalies, and are
+	// reported by Schema.OutOfRange.
+	Min *float64 `json:",omitempty"`
+	Max *float64 `json:",omitempty"`
+}
+
+// Schema names the attributes of the vectors, in the order of the columns of
+// the training data.
+type Schema struct {
+	Features []Feature
+}
+
+// NewSchema returns a schema of numeric features with given names and the
+// ranges of their values in X, if not nil.
+func NewSchema(names []string, X [][]float64) (*Schema, error) {
+	s := &Schema{Features: make([]Feature, len(names))}
+	for j, name := range names {
+		s.Features[j] = Feature{Name: name, Type: NumericFeature}
+	}
+	if err := s.check(); err != nil {
+		return nil, err
+	}
+	if len(X) == 0 {
+		return s, nil
+	}
+
+	for j := range s.Features {
+		min, max := math.Inf(1), math.Inf(-1)
+		for i := range X {
+			if len(X[i]) != len(names) {
+				return nil, fmt.Errorf("vector %d has %d attributes, want %d", i, len(X[i]), len(names))
+			}
+			min = math.Min(min, X[i][j])
+			max = math.Max(max, X[i][j])
+		}
+		s.Features[j].Min, s.Features[j].Max = &min, &max
+	}
+	return s, nil
+}
+
+// clone returns a copy of the schema, nil for a nil schema.
+func (s *Schema) clone() *Schema {
+	if s == nil {
+		return nil
+	}
+	c := &Schema{Features: append([]Feature(nil), s.Features...)}
+	for j, feature := range c.Features {
+		if feature.Min != nil {
+			min := *feature.Min
+			c.Features[j].Min = &min
+		}
+		if feature.Max != nil {
+			max := *feature.Max
+			c.Features[j].Max = &max
+		}
+	}
+	return c
+}
+
+// Names returns the names of the features.
+func (s *Schema) Names() []string {
+	names := make([]string, len(s.Features))
+	for j, feature := range s.Features {
+		names[j] = feature.Name
+	}
+	return names
+}
+
+// Vector maps the named fields of a record to a vector. Missing or
+// unexpected fields and values not matching the type of their feature are
+// errors.
+func (s *Schema) Vector(record map[string]float64) ([]float64, error) {
+	x := make([]float64, len(s.Features))
+	for j, feature := range s.Features {
+		v, ok := record[feature.Name]
+		if !ok {
+			return nil, fmt.Errorf("missing field %q", feature.Name)
+		}
+		if err := feature.check(v); err != nil {
+			return nil, err
+		}
+		x[j] = v
+	}
+	if len(record) != len(s.Features) {
+		for name := range record {
+			if s.index(name) < 0 {
+				return nil, fmt.Errorf("unexpected field %q", name)
+			}
+		}
+	}
+	return x, nil
+}
+
+// OutOfRange returns the names of the features whose value in x is out of
+// the expected range.
+func (s *Schema) OutOfRange(x []float64) []string {
+	var names []string
+	for j, feature := range s.Features {
+		if j >= len(x) {
+			break
+		}
+		if (feature.Min != nil && x[j] < *feature.Min) || (feature.Max != nil && x[j] > *feature.Max) {
+			names = append(names, feature.Name)
+		}
+	}
+	return names
+}
+
+// index returns the column of the named feature, -1 if there is none.
+func (s *Schema) index(name string) int {
+	for j, feature := range s.Features {
+		if feature.Name == name {
+			return j
+		}
+	}
+	return -1
+}
+
+// check verifies that the features are named uniquely and have valid types
+// and ranges.
+func (s *Schema) check() error {
+	if len(s.Features) == 0 {
+		return errors.New("schema has no feature")
+	}
+	names := make(map[string]bool, len(s.Features))
+	for _, feature := range s.Features {
+		if feature.Name == "" {
+			return errors.New("schema features must be named")
+		}
+		if names[feature.Name] {
+			return fmt.Errorf("duplicate feature %q", feature.Name)
+		}
+		names[feature.Name] = true
+		switch feature.Type {
+		case "", NumericFeature, IntegerFeature, BooleanFeature:
+		default:
+			return fmt.Errorf("feature %q has unknown type %q", feature.Name, feature.Type)
+		}
+		if feature.Min != nil && feature.Max != nil && *feature.Min > *feature.Max {
+			return fmt.Errorf("feature %q has an empty range", feature.Name)
+		}
+	}
+	return nil
+}
+
+// check verifies that a value matches the type of the feature.
+func (feature Feature) check(v float64) error {
+	if math.IsNaN(v) || math.IsInf(v, 0) {
+		return fmt.Errorf("field %q is not a finite number", feature.Name)
+	}
+	switch feature.Type {
+	case IntegerFeature:
+		if v != math.Trunc(v) {
+			return fmt.Errorf("field %q must be an integer", feature.Name)
+		}
+	case BooleanFeature:
+		if v != 0 && v != 1 {
+			return fmt.Errorf("field %q must be 0 or 1", feature.Name)
+		}
+	}
+	return nil
+}
+
+// checkSchema verifies the schema of the forest against its number of
+// features.
+func (f *Forest) checkSchema() error {
+	if f.Schema == nil {
+		return errors.New("model has no schema")
+	}
+	if err := f.Schema.check(); err != nil {
+		return err
+	}
+	if n := nbFeatures(f); n > len(f.Schema.Features) || (f.NbFeatures > 0 && n != len(f.Schema.Features)) {
+		return fmt.Errorf("schema has %d features, model has %d", len(f.Schema.Features), n)
+	}
+	return nil
+}
+
+// PredictRecords does the same as Predict with records mapping the names of
+// the schema features to their values.
+func (f *Forest) PredictRecords(records []map[string]float64) ([]int, []float64, error) {
+	if !f.Trained {
+		return nil, nil, errors.New("cannot predict - model has not been trained yet")
+	}
+	if err := f.checkSchema(); err != nil {
+		return nil, nil, fmt.Errorf("cannot predict records - %v", err)
+	}
+
+	X := make([][]float64, len(records))
+	for i, record := range records {
+		x, err := f.Schema.Vector(record)
+		if err != nil {
+			return nil, nil, fmt.Errorf("record %d: %v", i, err)
+		}
+		X[i] = x
+	}
+	return f.Predict(X)
+}
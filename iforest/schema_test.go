@@ -0,0 +1,132 @@
+package iforest
+
+import (
+	"math"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestNewSchema(t *testing.T) {
+	s, err := NewSchema([]string{"a", "b"}, [][]float64{{1, 5}, {-2, 3}, {0, 4}})
+	if err != nil {
+		t.Fatalf("NewSchema() error = %v", err)
+	}
+	if !reflect.DeepEqual(s.Names(), []string{"a", "b"}) {
+		t.Errorf("Schema.Names() = %v, want [a b]", s.Names())
+	}
+	for j, want := range [][2]float64{{-2, 1}, {3, 5}} {
+		f := s.Features[j]
+		if f.Type != NumericFeature || f.Min == nil || f.Max == nil || *f.Min != want[0] || *f.Max != want[1] {
+			t.Errorf("NewSchema() feature %v = %+v, want range %v", j, f, want)
+		}
+	}
+	if got := s.OutOfRange([]float64{2, 4}); !reflect.DeepEqual(got, []string{"a"}) {
+		t.Errorf("Schema.OutOfRange() = %v, want [a]", got)
+	}
+	if got := s.OutOfRange([]float64{1, 3}); got != nil {
+		t.Errorf("Schema.OutOfRange() = %v, want none", got)
+	}
+
+	errors := []struct {
+		names []string
+		X     [][]float64
+	}{
+		{names: nil},
+		{names: []string{"a", ""}},
+		{names: []string{"a", "a"}},
+		{names: []string{"a", "b"}, X: [][]float64{{1, 2}, {3}}},
+	}
+	for _, tt := range errors {
+
+		if _, err := NewSchema(tt.names, tt.X); err == nil {
+			t.Errorf("NewSchema(%v, %v) error = nil, want an error", tt.names, tt.X)
+		}
+
+	}
+}
+
+func TestSchema_Vector(t *testing.T) {
+	s := &Schema{Features: []Feature{
+		{Name: "x"},
+		{Name: "count", Type: IntegerFeature},
+		{Name: "flag", Type: BooleanFeature},
+	}}
+	tests := []struct {
+		name    string
+		record  map[string]float64
+		want    []float64
+		wantErr bool
+	}{
+		{name: "valid", record: map[string]float64{"flag": 1, "x": 0.5, "count": 3}, want: []float64{0.5, 3, 1}},
+		{name: "missing", record: map[string]float64{"x": 0.5, "count": 3}, wantErr: true},
+		{name: "unexpected", record: map[string]float64{"flag": 1, "x": 0.5, "count": 3, "y": 2}, wantErr: true},
+		{name: "not an integer", record: map[string]float64{"flag": 1, "x": 0.5, "count": 3.5}, wantErr: true},
+		{name: "not a boolean", record: map[string]float64{"flag": 2, "x": 0.5, "count": 3}, wantErr: true},
+		{name: "NaN", record: map[string]float64{"flag": 1, "x": math.NaN(), "count": 3}, wantErr: true},
+	}
+	for _, tt := range tests {
+
+		got, err := s.Vector(tt.record)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: Schema.Vector() error = %v, wantErr %v", tt.name, err, tt.wantErr)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: Schema.Vector() = %v, want %v", tt.name, got, tt.want)
+		}
+
+	}
+}
+
+func TestForest_PredictRecords(t *testing.T) {
+	_, X := randomData32(300, 3)
+	f := NewForest(50, 64, 0.02)
+	f.Seed = 1
+	f.Train(X)
+	if err := f.Test(X); err != nil {
+		t.Fatal(err)
+	}
+
+	records := []map[string]float64{{"a": X[0][0], "b": X[0][1], "c": X[0][2]}, {"c": X[1][2], "b": X[1][1], "a": X[1][0]}}
+	if _, _, err := f.PredictRecords(records); err == nil {
+		t.Errorf("Forest.PredictRecords() without schema error = nil, want an error")
+	}
+	var err error
+	if f.Schema, err = NewSchema([]string{"a", "b", "c"}, X); err != nil {
+		t.Fatal(err)
+	}
+
+	// the schema is saved with the model
+	path := filepath.Join(t.TempDir(), "model.json")
+	if err := f.Save(path); err != nil {
+		t.Fatal(err)
+	}
+	loaded := &Forest{}
+	if err := loaded.Load(path); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(loaded.Schema, f.Schema) {
+		t.Errorf("Forest.Load() schema = %+v, want %+v", loaded.Schema, f.Schema)
+	}
+
+	labels, scores, err := loaded.PredictRecords(records)
+	if err != nil {
+		t.Fatalf("Forest.PredictRecords() error = %v", err)
+	}
+	wantLabels, wantScores, _ := f.Predict(X[:2])
+	if !reflect.DeepEqual(labels, wantLabels) || !reflect.DeepEqual(scores, wantScores) {
+		t.Errorf("Forest.PredictRecords() = %v %v, want %v %v", labels, scores, wantLabels, wantScores)
+	}
+
+	if _, _, err := loaded.PredictRecords([]map[string]float64{records[0], {"a": 1, "b": 2}}); err == nil {
+		t.Errorf("Forest.PredictRecords() with a missing field error = nil, want an error")
+	}
+	loaded.Schema.Features = loaded.Schema.Features[:2]
+	if _, _, err := loaded.PredictRecords(nil); err == nil {
+		t.Errorf("Forest.PredictRecords() with a short schema error = nil, want an error")
+	}
+	if _, _, err := NewForest(10, 32, 0.1).PredictRecords(nil); err == nil {
+		t.Errorf("Forest.PredictRecords() before training error = nil, want an error")
+	}
+}
@@ -0,0 +1,120 @@
+// Package client calls the Scorer gRPC service with the input and output types
+// of the iforest package.
+package client
+
+import (
+	"context"
+
+	"google.golang.org/grpc"
+
+	"github.com/e-XpertSolutions/go-iforest/v2/iforest/scoring/scoringpb"
+)
+
+// Client calls a Scorer service.
+type Client struct {
+	scorer scoringpb.ScorerClient
+}
+
+// New returns a client calling the Scorer service over given connection.
+func New(conn grpc.ClientConnInterface) *Client {
+	return &Client{scorer: scoringpb.NewScorerClient(conn)}
+}
+
+// Explanation holds the path lengths which the score of a vector is computed
+// from.
+type Explanation struct {
+	AveragePathLength float64
+	PathLengths       []float64
+}
+
+// Result holds the labels, 1 for anomalies and 0 for normal vectors, and the
+// scores of vectors. Explanations are only set when asked for.
+type Result struct {
+	Labels       []int
+	Scores       []float64
+	Explanations []Explanation
+}
+
+// Score scores a batch of vectors.
+func (c *Client) Score(ctx context.Context, X [][]float64, explain bool) (*Result, error) {
+	resp, err := c.scorer.Score(ctx, newRequest(X, explain))
+	if err != nil {
+		return nil, err
+	}
+	return newResult(resp), nil
+}
+
+// ScoreStream streams the batches to the service and returns the result of
+// all of them, in order.
+func (c *Client) ScoreStream(ctx context.Context, batches [][][]float64, explain bool) (*Result, error) {
+	stream, err := c.scorer.ScoreStream(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, X := range batches {
+		if err := stream.Send(newRequest(X, explain)); err != nil {
+			// the error of the call is returned by CloseAndRecv
+			break
+		}
+	}
+	resp, err := stream.CloseAndRecv()
+	if err != nil {
+		return nil, err
+	}
+	return newResult(resp), nil
+}
+
+// BatchStream sends batches of vectors to the service and receives their
+// results as soon as they are scored, in the order of the batches.
+type BatchStream struct {
+	stream  scoringpb.Scorer_ScoreBatchesClient
+	explain bool
+}
+
+// ScoreBatches opens a bidirectional stream of batches.
+func (c *Client) ScoreBatches(ctx context.Context, explain bool) (*BatchStream, error) {
+	stream, err := c.scorer.ScoreBatches(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &BatchStream{stream: stream, explain: explain}, nil
+}
+
+// Send sends a batch of vectors.
+func (s *BatchStream) Send(X [][]float64) error {
+	return s.stream.Send(newRequest(X, s.explain))
+}
+
+// Recv receives the result of the next batch. It returns io.EOF once the
+// results of all batches were received after CloseSend.
+func (s *BatchStream) Recv() (*Result, error) {
+	resp, err := s.stream.Recv()
+	if err != nil {
+		return nil, err
+	}
+	return newResult(resp), nil
+}
+
+// CloseSend tells the service that no more batches will be sent.
+func (s *BatchStream) CloseSend() error {
+	return s.stream.CloseSend()
+}
+
+func newRequest(X [][]float64, explain bool) *scoringpb.ScoreRequest {
+	req := &scoringpb.ScoreRequest{Vectors: make([]*scoringpb.Vector, len(X)), Explain: explain}
+	for i, x := range X {
+		req.Vectors[i] = &scoringpb.Vector{Values: x}
+	}
+	return req
+}
+
+func newResult(resp *scoringpb.ScoreResponse) *Result {
+	r := &Result{Labels: make([]int, len(resp.Labels)), Scores: resp.Scores}
+	for i, l := range resp.Labels {
+		r.Labels[i] = int(l)
+	}
+	for _, e := range resp.Explanations {
+		r.Explanations = append(r.Explanations, Explanation{AveragePathLength: e.AveragePathLength, PathLengths: e.PathLengths})
+	}
+	return r
+}
@@ -0,0 +1,31 @@
+package client
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/e-XpertSolutions/go-iforest/v2/iforest/scoring/scoringpb"
+)
+
+func Test_newRequest(t *testing.T) {
+	req := newRequest([][]float64{{1, 2}, {3, 4}}, true)
+	if !req.Explain || len(req.Vectors) != 2 || !reflect.DeepEqual(req.Vectors[1].Values, []float64{3, 4}) {
+		t.Errorf("newRequest() = %v", req)
+	}
+}
+
+func Test_newResult(t *testing.T) {
+	resp := &scoringpb.ScoreResponse{
+		Labels:       []int32{0, 1},
+		Scores:       []float64{0.1, -0.2},
+		Explanations: []*scoringpb.Explanation{{AveragePathLength: 3, PathLengths: []float64{2, 4}}, {AveragePathLength: 1, PathLengths: []float64{1, 1}}},
+	}
+	want := &Result{
+		Labels:       []int{0, 1},
+		Scores:       []float64{0.1, -0.2},
+		Explanations: []Explanation{{AveragePathLength: 3, PathLengths: []float64{2, 4}}, {AveragePathLength: 1, PathLengths: []float64{1, 1}}},
+	}
+	if got := newResult(resp); !reflect.DeepEqual(got, want) {
+		t.Errorf("newResult() = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,120 @@
+// Package scoring serves the model of an iforest.Holder over gRPC, with the
+// Scorer service defined in the scoringpb package.
+//
+// Batches are scored with the model held when they are received, reloading
+// the holder does not interrupt streams.
+package scoring
+
+import (
+	"context"
+	"errors"
+	"io"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/e-XpertSolutions/go-iforest/v2/iforest"
+	"github.com/e-XpertSolutions/go-iforest/v2/iforest/scoring/scoringpb"
+)
+
+// Server implements the Scorer service.
+type Server struct {
+	scoringpb.UnimplementedScorerServer
+
+	holder *iforest.Holder
+}
+
+// NewServer returns a server scoring vectors with the model of the holder.
+func NewServer(h *iforest.Holder) *Server {
+	return &Server{holder: h}
+}
+
+// Score scores a batch of vectors.
+func (s *Server) Score(ctx context.Context, req *scoringpb.ScoreRequest) (*scoringpb.ScoreResponse, error) {
+	return s.score(req)
+}
+
+// ScoreStream scores the batches sent by the client and returns all of them
+// at once.
+func (s *Server) ScoreStream(stream scoringpb.Scorer_ScoreStreamServer) error {
+	resp := &scoringpb.ScoreResponse{}
+	for {
+		req, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			return stream.SendAndClose(resp)
+		}
+		if err != nil {
+			return err
+		}
+		batch, err := s.score(req)
+		if err != nil {
+			return err
+		}
+		resp.Labels = append(resp.Labels, batch.Labels...)
+		resp.Scores = append(resp.Scores, batch.Scores...)
+		resp.Explanations = append(resp.Explanations, batch.Explanations...)
+	}
+}
+
+// ScoreBatches sends back the scores of each batch sent by the client.
+func (s *Server) ScoreBatches(stream scoringpb.Scorer_ScoreBatchesServer) error {
+	for {
+		req, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		resp, err := s.score(req)
+		if err != nil {
+			return err
+		}
+		if err := stream.Send(resp); err != nil {
+			return err
+		}
+	}
+}
+
+// score scores a batch with the current model.
+func (s *Server) score(req *scoringpb.ScoreRequest) (*scoringpb.ScoreResponse, error) {
+	features := s.holder.Features()
+	X := make([][]float64, len(req.Vectors))
+	for i, v := range req.Vectors {
+		if len(v.Values) != features {
+			return nil, status.Errorf(codes.InvalidArgument, "vector %d has %d features, want %d", i, len(v.Values), features)
+		}
+		X[i] = v.Values
+	}
+	resp := &scoringpb.ScoreResponse{}
+	if len(X) == 0 {
+		return resp, nil
+	}
+
+	f := s.holder.Forest()
+	labels, scores, err := f.Predict(X)
+	if err != nil {
+		return nil, status.Error(codes.FailedPrecondition, err.Error())
+	}
+	resp.Labels = make([]int32, len(labels))
+	for i, l := range labels {
+		resp.Labels[i] = int32(l)
+	}
+	resp.Scores = scores
+
+	if req.Explain {
+		averages, err := f.AveragePathLength(X)
+		if err != nil {
+			return nil, status.Error(codes.FailedPrecondition, err.Error())
+		}
+		resp.Explanations = make([]*scoringpb.Explanation, len(X))
+		for i, x := range X {
+			paths, err := f.PathLengths(x)
+			if err != nil {
+				return nil, status.Error(codes.FailedPrecondition, err.Error())
+			}
+			resp.Explanations[i] = &scoringpb.Explanation{AveragePathLength: averages[i], PathLengths: paths}
+		}
+	}
+	return resp, nil
+}
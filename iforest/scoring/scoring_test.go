@@ -0,0 +1,154 @@
+package scoring
+
+import (
+	"context"
+	"io"
+	"math/rand"
+	"net"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+
+	"github.com/e-XpertSolutions/go-iforest/v2/iforest"
+	"github.com/e-XpertSolutions/go-iforest/v2/iforest/scoring/client"
+	"github.com/e-XpertSolutions/go-iforest/v2/iforest/scoring/scoringpb"
+)
+
+// startServer serves a forest trained on random data over an in-memory
+// listener and returns a client connected to it.
+func startServer(t *testing.T) (*client.Client, *iforest.Forest, [][]float64) {
+	rnd := rand.New(rand.NewSource(1))
+	X := make([][]float64, 200)
+	for i := range X {
+		X[i] = []float64{rnd.NormFloat64(), rnd.NormFloat64(), rnd.NormFloat64()}
+		if i%50 == 0 {
+			X[i][1] += 10
+		}
+	}
+	f := iforest.NewForest(20, 64, 0.02)
+	f.Seed = 1
+	f.Train(X)
+	f.Test(X)
+	path := filepath.Join(t.TempDir(), "model.json")
+	if err := f.Save(path); err != nil {
+		t.Fatalf("Forest.Save() error = %v", err)
+	}
+	h, err := iforest.NewHolder(path)
+	if err != nil {
+		t.Fatalf("iforest.NewHolder() error = %v", err)
+	}
+
+	l := bufconn.Listen(1 << 20)
+	s := grpc.NewServer()
+	scoringpb.RegisterScorerServer(s, NewServer(h))
+	go s.Serve(l)
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return l.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("grpc.NewClient() error = %v", err)
+	}
+	t.Cleanup(func() {
+		conn.Close()
+		s.Stop()
+	})
+	return client.New(conn), h.Forest(), X
+}
+
+func TestServer_Score(t *testing.T) {
+	c, f, X := startServer(t)
+	ctx := context.Background()
+	wantLabels, wantScores, _ := f.Predict(X)
+
+	r, err := c.Score(ctx, X, false)
+	if err != nil {
+		t.Fatalf("Client.Score() error = %v", err)
+	}
+	if !reflect.DeepEqual(r.Labels, wantLabels) || !reflect.DeepEqual(r.Scores, wantScores) || r.Explanations != nil {
+		t.Errorf("Client.Score() = %v, %v, %v explanations", r.Labels, r.Scores, len(r.Explanations))
+	}
+
+	r, err = c.Score(ctx, X[:3], true)
+	if err != nil {
+		t.Fatalf("Client.Score() with explanations error = %v", err)
+	}
+	averages, _ := f.AveragePathLength(X[:3])
+	for i := range r.Explanations {
+		paths, _ := f.PathLengths(X[i])
+		want := client.Explanation{AveragePathLength: averages[i], PathLengths: paths}
+		if !reflect.DeepEqual(r.Explanations[i], want) {
+			t.Errorf("Client.Score() explanation = %v, want %v", r.Explanations[i], want)
+		}
+	}
+	if len(r.Explanations) != 3 {
+		t.Errorf("Client.Score() = %v explanations, want 3", len(r.Explanations))
+	}
+
+	if r, err := c.Score(ctx, nil, false); err != nil || len(r.Scores) != 0 {
+		t.Errorf("Client.Score() without vectors = %v, %v", r, err)
+	}
+	_, err = c.Score(ctx, [][]float64{{1, 2}}, false)
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("Client.Score() with 2 features error = %v, want InvalidArgument", err)
+	}
+}
+
+func TestServer_ScoreStream(t *testing.T) {
+	c, f, X := startServer(t)
+	ctx := context.Background()
+	wantLabels, wantScores, _ := f.Predict(X)
+
+	r, err := c.ScoreStream(ctx, [][][]float64{X[:50], X[50:120], nil, X[120:]}, true)
+	if err != nil {
+		t.Fatalf("Client.ScoreStream() error = %v", err)
+	}
+	if !reflect.DeepEqual(r.Labels, wantLabels) || !reflect.DeepEqual(r.Scores, wantScores) || len(r.Explanations) != len(X) {
+		t.Errorf("Client.ScoreStream() = %v, %v, %v explanations", r.Labels, r.Scores, len(r.Explanations))
+	}
+
+	_, err = c.ScoreStream(ctx, [][][]float64{X[:5], {{1}}, X[5:]}, false)
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("Client.ScoreStream() with an invalid batch error = %v, want InvalidArgument", err)
+	}
+}
+
+func TestServer_ScoreBatches(t *testing.T) {
+	c, f, X := startServer(t)
+	ctx := context.Background()
+	_, wantScores, _ := f.Predict(X)
+
+	stream, err := c.ScoreBatches(ctx, false)
+	if err != nil {
+		t.Fatalf("Client.ScoreBatches() error = %v", err)
+	}
+	for k := 0; k < 4; k++ {
+		if err := stream.Send(X[k*50 : (k+1)*50]); err != nil {
+			t.Fatalf("BatchStream.Send() error = %v", err)
+		}
+		r, err := stream.Recv()
+		if err != nil {
+			t.Fatalf("BatchStream.Recv() error = %v", err)
+		}
+		if !reflect.DeepEqual(r.Scores, wantScores[k*50:(k+1)*50]) {
+			t.Errorf("BatchStream.Recv() batch %d = %v", k, r.Scores)
+		}
+	}
+	stream.CloseSend()
+	if _, err := stream.Recv(); err != io.EOF {
+		t.Errorf("BatchStream.Recv() after CloseSend error = %v, want io.EOF", err)
+	}
+
+	stream, _ = c.ScoreBatches(ctx, false)
+	stream.Send([][]float64{{1, 2, 3, 4}})
+	if _, err := stream.Recv(); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("BatchStream.Recv() of an invalid batch error = %v, want InvalidArgument", err)
+	}
+}
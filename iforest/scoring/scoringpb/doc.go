@@ -0,0 +1,5 @@
+// Package scoringpb holds the gRPC service definition of the scoring service
+// and the code generated from it.
+package scoringpb
+
+//go:generate protoc --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative scoring.proto
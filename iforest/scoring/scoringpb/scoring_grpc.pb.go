@@ -0,0 +1,267 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.4.0
+// - protoc             (unknown)
+// source: scoring.proto
+
+package scoringpb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.62.0 or later.
+const _ = grpc.SupportPackageIsVersion8
+
+const (
+	Scorer_Score_FullMethodName        = "/iforest.scoring.v1.Scorer/Score"
+	Scorer_ScoreStream_FullMethodName  = "/iforest.scoring.v1.Scorer/ScoreStream"
+	Scorer_ScoreBatches_FullMethodName = "/iforest.scoring.v1.Scorer/ScoreBatches"
+)
+
+// ScorerClient is the client API for Scorer service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// Scorer scores vectors with an isolation forest.
+type ScorerClient interface {
+	// Score scores a batch of vectors.
+	Score(ctx context.Context, in *ScoreRequest, opts ...grpc.CallOption) (*ScoreResponse, error)
+	// ScoreStream scores the vectors of all the batches sent by the client and
+	// returns them at once, in order.
+	ScoreStream(ctx context.Context, opts ...grpc.CallOption) (Scorer_ScoreStreamClient, error)
+	// ScoreBatches returns a response for each batch sent by the client, as
+	// soon as it is scored.
+	ScoreBatches(ctx context.Context, opts ...grpc.CallOption) (Scorer_ScoreBatchesClient, error)
+}
+
+type scorerClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewScorerClient(cc grpc.ClientConnInterface) ScorerClient {
+	return &scorerClient{cc}
+}
+
+func (c *scorerClient) Score(ctx context.Context, in *ScoreRequest, opts ...grpc.CallOption) (*ScoreResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ScoreResponse)
+	err := c.cc.Invoke(ctx, Scorer_Score_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *scorerClient) ScoreStream(ctx context.Context, opts ...grpc.CallOption) (Scorer_ScoreStreamClient, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &Scorer_ServiceDesc.Streams[0], Scorer_ScoreStream_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &scorerScoreStreamClient{ClientStream: stream}
+	return x, nil
+}
+
+type Scorer_ScoreStreamClient interface {
+	Send(*ScoreRequest) error
+	CloseAndRecv() (*ScoreResponse, error)
+	grpc.ClientStream
+}
+
+type scorerScoreStreamClient struct {
+	grpc.ClientStream
+}
+
+func (x *scorerScoreStreamClient) Send(m *ScoreRequest) error {
+	return x.ClientStream.SendMsg(m)
+}
+
+func (x *scorerScoreStreamClient) CloseAndRecv() (*ScoreResponse, error) {
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	m := new(ScoreResponse)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *scorerClient) ScoreBatches(ctx context.Context, opts ...grpc.CallOption) (Scorer_ScoreBatchesClient, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &Scorer_ServiceDesc.Streams[1], Scorer_ScoreBatches_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &scorerScoreBatchesClient{ClientStream: stream}
+	return x, nil
+}
+
+type Scorer_ScoreBatchesClient interface {
+	Send(*ScoreRequest) error
+	Recv() (*ScoreResponse, error)
+	grpc.ClientStream
+}
+
+type scorerScoreBatchesClient struct {
+	grpc.ClientStream
+}
+
+func (x *scorerScoreBatchesClient) Send(m *ScoreRequest) error {
+	return x.ClientStream.SendMsg(m)
+}
+
+func (x *scorerScoreBatchesClient) Recv() (*ScoreResponse, error) {
+	m := new(ScoreResponse)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// ScorerServer is the server API for Scorer service.
+// All implementations must embed UnimplementedScorerServer
+// for forward compatibility
+//
+// Scorer scores vectors with an isolation forest.
+type ScorerServer interface {
+	// Score scores a batch of vectors.
+	Score(context.Context, *ScoreRequest) (*ScoreResponse, error)
+	// ScoreStream scores the vectors of all the batches sent by the client and
+	// returns them at once, in order.
+	ScoreStream(Scorer_ScoreStreamServer) error
+	// ScoreBatches returns a response for each batch sent by the client, as
+	// soon as it is scored.
+	ScoreBatches(Scorer_ScoreBatchesServer) error
+	mustEmbedUnimplementedScorerServer()
+}
+
+// UnimplementedScorerServer must be embedded to have forward compatible implementations.
+type UnimplementedScorerServer struct {
+}
+
+func (UnimplementedScorerServer) Score(context.Context, *ScoreRequest) (*ScoreResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Score not implemented")
+}
+func (UnimplementedScorerServer) ScoreStream(Scorer_ScoreStreamServer) error {
+	return status.Errorf(codes.Unimplemented, "method ScoreStream not implemented")
+}
+func (UnimplementedScorerServer) ScoreBatches(Scorer_ScoreBatchesServer) error {
+	return status.Errorf(codes.Unimplemented, "method ScoreBatches not implemented")
+}
+func (UnimplementedScorerServer) mustEmbedUnimplementedScorerServer() {}
+
+// UnsafeScorerServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to ScorerServer will
+// result in compilation errors.
+type UnsafeScorerServer interface {
+	mustEmbedUnimplementedScorerServer()
+}
+
+func RegisterScorerServer(s grpc.ServiceRegistrar, srv ScorerServer) {
+	s.RegisterService(&Scorer_ServiceDesc, srv)
+}
+
+func _Scorer_Score_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ScoreRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ScorerServer).Score(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Scorer_Score_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ScorerServer).Score(ctx, req.(*ScoreRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Scorer_ScoreStream_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(ScorerServer).ScoreStream(&scorerScoreStreamServer{ServerStream: stream})
+}
+
+type Scorer_ScoreStreamServer interface {
+	SendAndClose(*ScoreResponse) error
+	Recv() (*ScoreRequest, error)
+	grpc.ServerStream
+}
+
+type scorerScoreStreamServer struct {
+	grpc.ServerStream
+}
+
+func (x *scorerScoreStreamServer) SendAndClose(m *ScoreResponse) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func (x *scorerScoreStreamServer) Recv() (*ScoreRequest, error) {
+	m := new(ScoreRequest)
+	if err := x.ServerStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func _Scorer_ScoreBatches_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(ScorerServer).ScoreBatches(&scorerScoreBatchesServer{ServerStream: stream})
+}
+
+type Scorer_ScoreBatchesServer interface {
+	Send(*ScoreResponse) error
+	Recv() (*ScoreRequest, error)
+	grpc.ServerStream
+}
+
+type scorerScoreBatchesServer struct {
+	grpc.ServerStream
+}
+
+func (x *scorerScoreBatchesServer) Send(m *ScoreResponse) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func (x *scorerScoreBatchesServer) Recv() (*ScoreRequest, error) {
+	m := new(ScoreRequest)
+	if err := x.ServerStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// Scorer_ServiceDesc is the grpc.ServiceDesc for Scorer service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Scorer_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "iforest.scoring.v1.Scorer",
+	HandlerType: (*ScorerServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Score",
+			Handler:    _Scorer_Score_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "ScoreStream",
+			Handler:       _Scorer_ScoreStream_Handler,
+			ClientStreams: true,
+		},
+		{
+			StreamName:    "ScoreBatches",
+			Handler:       _Scorer_ScoreBatches_Handler,
+			ServerStreams: true,
+			ClientStreams: true,
+		},
+	},
+	Metadata: "scoring.proto",
+}
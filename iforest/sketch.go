@@ -0,0 +1,102 @@
+package iforest
+
+import (
+	"errors"
+	"math"
+)
+
+// DefaultSketchSize is the number of quantiles kept by the sketch built during
+// the testing stage.
+const DefaultSketchSize = 1001
+
+// ScoreSketch is a compact summary of the anomaly scores of a dataset. It keeps
+// the scores found at evenly spaced ranks of the sorted dataset, including the
+// lowest and the highest one. Datasets smaller than the sketch are kept whole,
+// so quantiles computed from the sketch are then exact.
+type ScoreSketch struct {
+	Count     int
+	Quantiles []float64
+}
+
+// clone returns a copy of the sketch, nil for a nil sketch.
+func (s *ScoreSketch) clone() *ScoreSketch {
+	if s == nil {
+		return nil
+	}
+	return &ScoreSketch{Count: s.Count, Quantiles: append([]float64(nil), s.Quantiles...)}
+}
+
+// NewScoreSketch summarizes the scores with at most size quantiles.
+func NewScoreSketch(scores []float64, size int) (*ScoreSketch, error) {
+	if len(scores) == 0 {
+		return nil, errors.New("cannot summarize scores - no scores")
+	}
+	if size < 2 {
+		return nil, errors.New("sketch size must be at least 2")
+	}
+	sorted := sortedCopy(scores)
+	if len(sorted) <= size {
+		return &ScoreSketch{Count: len(sorted), Quantiles: sorted}, nil
+	}
+
+	quantiles := make([]float64, size)
+	for i := range quantiles {
+		quantiles[i] = quantile(sorted, float64(i)/float64(size-1))
+	}
+	return &ScoreSketch{Count: len(sorted), Quantiles: quantiles}, nil
+}
+
+// AtRank returns the score found at the given rank of the sorted dataset. Rank
+// goes from 0 for the lowest score to Count-1 for the highest one, scores
+// between summarized ranks are interpolated.
+func (s *ScoreSketch) AtRank(rank float64) float64 {
+	if s.Count <= 1 {
+		return s.Quantiles[0]
+	}
+	return quantile(s.Quantiles, rank/float64(s.Count-1))
+}
+
+// Contamination computes the anomaly bound which labels the given ratio of the
+// summarized scores as anomalies, as Contamination.Threshold does on the whole
+// dataset.
+func (s *ScoreSketch) Contamination(ratio float64) (float64, error) {
+	if ratio < 0 || ratio > 1 {
+		return 0, errors.New("contamination ratio must be between 0 and 1")
+	}
+	return contaminationBound(s.Count, ratio, s.AtRank), nil
+}
+
+// SetContamination changes the anomaly ratio and recomputes the anomaly bound
+// from the scores sketch kept since the testing stage, without testing the
+// model again. Labels of the tested dataset are updated when its scores are
+// available.
+func (f *Forest) SetContamination(ratio float64) error {
+	if !f.Tested || f.ScoreSketch == nil {
+		return errors.New("cannot set contamination - model has no scores sketch, it has to be tested first")
+	}
+	bound, err := f.ScoreSketch.Contamination(ratio)
+	if err != nil {
+		return err
+	}
+	f.AnomalyRatio = ratio
+	f.AnomalyBound = bound
+	f.Thresholder = nil
+	f.TunedBound = nil
+
+	if len(f.AnomalyScores) == len(f.Labels) {
+		f.label(len(f.Labels))
+	}
+	return nil
+}
+
+// contaminationBound chooses the bound between the scores at the floor and the
+// ceiling of ratio*n, given the score at each rank.
+func contaminationBound(n int, ratio float64, at func(rank float64) float64) float64 {
+	anomFloor := math.Floor(ratio * float64(n))
+	anomCeil := math.Ceil(ratio * float64(n))
+	if int(anomCeil) >= n {
+		// every score has to be lower than the bound
+		return math.Nextafter(at(float64(n-1)), math.Inf(1))
+	}
+	return (at(anomFloor) + at(anomCeil)) / 2
+}
@@ -0,0 +1,107 @@
+package iforest
+
+import (
+	"math"
+	"path/filepath"
+	"testing"
+)
+
+func TestNewScoreSketch(t *testing.T) {
+	tests := []struct {
+		scores  []float64
+		size    int
+		wantLen int
+		wantErr bool
+	}{
+		{scores: []float64{3, 1, 2}, size: 10, wantLen: 3},
+		{scores: evenScores(100), size: 11, wantLen: 11},
+		{scores: nil, size: 10, wantErr: true},
+		{scores: []float64{1}, size: 1, wantErr: true},
+	}
+	for _, tt := range tests {
+
+		got, err := NewScoreSketch(tt.scores, tt.size)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("NewScoreSketch() error = %v, wantErr %v", err, tt.wantErr)
+			continue
+		}
+		if err != nil {
+			continue
+		}
+		if len(got.Quantiles) != tt.wantLen || got.Count != len(tt.scores) {
+			t.Errorf("NewScoreSketch() = %v quantiles of %v scores, want %v of %v", len(got.Quantiles), got.Count, tt.wantLen, len(tt.scores))
+		}
+		min, max := minMax(tt.scores)
+		if got.AtRank(0) != min || got.AtRank(float64(got.Count-1)) != max {
+			t.Errorf("NewScoreSketch() ranges from %v to %v, want %v to %v", got.AtRank(0), got.AtRank(float64(got.Count-1)), min, max)
+		}
+
+	}
+}
+
+func TestScoreSketch_Contamination(t *testing.T) {
+	scores := bimodalScores(9500, 500)
+	sketch, _ := NewScoreSketch(scores, DefaultSketchSize)
+
+	for _, r := range []float64{0, 0.001, 0.01, 0.05, 0.1, 0.5, 1} {
+
+		want, _ := Contamination(r).Threshold(scores)
+		got, err := sketch.Contamination(r)
+		if err != nil {
+			t.Fatalf("ScoreSketch.Contamination(%v) error = %v", r, err)
+		}
+		// the bound may move within a gap of the scores, so the ratios of
+		// labelled scores are compared
+		wantRatio := float64(countBelow(scores, want)) / float64(len(scores))
+		gotRatio := float64(countBelow(scores, got)) / float64(len(scores))
+		if math.Abs(gotRatio-wantRatio) > 0.002 {
+			t.Errorf("ScoreSketch.Contamination(%v) = %v labels %v of scores, want %v", r, got, gotRatio, wantRatio)
+		}
+
+	}
+	if _, err := sketch.Contamination(2); err == nil {
+		t.Errorf("ScoreSketch.Contamination(2) should fail")
+	}
+}
+
+func TestForest_SetContamination(t *testing.T) {
+	X := [][]float64{{1, 1}, {1, 2}, {2, 1}, {2, 2}, {1, 1}, {10, 10}, {3, 2}, {-5, 1}}
+
+	f := NewForest(20, 8, 0.1)
+	if err := f.SetContamination(0.2); err == nil {
+		t.Errorf("Forest.SetContamination() should fail before testing")
+	}
+	f.Train(X)
+	f.Test(X)
+
+	path := filepath.Join(t.TempDir(), "model")
+	if err := f.Save(path); err != nil {
+		t.Fatalf("Forest.Save() error = %v", err)
+	}
+	loaded := &Forest{}
+	if err := loaded.Load(path); err != nil {
+		t.Fatalf("Forest.Load() error = %v", err)
+	}
+
+	for _, r := range []float64{0.25, 0.5, 1, 0} {
+
+		if err := loaded.SetContamination(r); err != nil {
+			t.Fatalf("Forest.SetContamination(%v) error = %v", r, err)
+		}
+		f.AnomalyRatio = r
+		f.Test(X)
+		if loaded.AnomalyBound != f.AnomalyBound {
+			t.Errorf("Forest.SetContamination(%v) bound = %v, want %v", r, loaded.AnomalyBound, f.AnomalyBound)
+		}
+		for i := range f.Labels {
+			if loaded.Labels[i] != f.Labels[i] {
+				t.Errorf("Forest.SetContamination(%v) labels = %v, want %v", r, loaded.Labels, f.Labels)
+				break
+			}
+		}
+
+	}
+	if err := loaded.SetContamination(-1); err == nil {
+		t.Errorf("Forest.SetContamination(-1) should fail")
+	}
+}
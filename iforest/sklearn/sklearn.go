@@ -0,0 +1,238 @@
+// Package sklearn imports models trained with scikit-learn's IsolationForest.
+//
+// Models are read from a JSON dump of the fitted estimator, written with:
+//
+//	import json
+//
+//	def dump(model, path):
+//	    estimators = []
+//	    for tree, features in zip(model.estimators_, model.estimators_features_):
+//	        t = tree.tree_
+//	        estimators.append({
+//	            "features": features.tolist(),
+//	            "children_left": t.children_left.tolist(),
+//	            "children_right": t.children_right.tolist(),
+//	            "feature": t.feature.tolist(),
+//	            "threshold": t.threshold.tolist(),
+//	            "n_node_samples": t.n_node_samples.tolist(),
+//	        })
+//	    d = {
+//	        "n_features_in": model.n_features_in_,
+//	        "max_samples": model.max_samples_,
+//	        "offset": model.offset_,
+//	        "contamination": 0 if model.contamination == "auto" else model.contamination,
+//	        "estimators": estimators,
+//	    }
+//	    if hasattr(model, "feature_names_in_"):
+//	        d["feature_names"] = model.feature_names_in_.tolist()
+//	    with open(path, "w") as f:
+//	        json.dump(d, f)
+//
+// The imported forest is trained and tested. Its scores are the ones of
+// score_samples shifted by 0.5, so that Predict labels as anomalies (1) the
+// vectors sklearn's predict labels as outliers (-1). The bound derived from
+// sklearn's offset is kept as a tuned bound, which testing the forest again
+// does not replace: use SetContamination or FitThreshold to choose another one.
+// As sklearn compares the
+// features of vectors cast to float32 with the thresholds, split values are
+// chosen so that float64 vectors take the same paths as in sklearn.
+package sklearn
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+
+	"github.com/e-XpertSolutions/go-iforest/v2/iforest"
+)
+
+// eulerGamma is the Euler's constant used by sklearn, more precise than
+// iforest.Euler.
+const eulerGamma = 0.5772156649015329
+
+// Model is the JSON dump of a fitted sklearn.ensemble.IsolationForest.
+type Model struct {
+	NbFeatures int     `json:"n_features_in"`
+	MaxSamples int     `json:"max_samples"`
+	Offset     float64 `json:"offset"`
+	// Contamination is the contamination parameter of the model, 0 when
+	// "auto".
+	Contamination float64     `json:"contamination"`
+	FeatureNames  []string    `json:"feature_names,omitempty"`
+	Estimators    []Estimator `json:"estimators"`
+}
+
+// Estimator is a tree of the model, its tree_ arrays and the features it was
+// trained on, estimators_features_.
+type Estimator struct {
+	Features      []int     `json:"features"`
+	ChildrenLeft  []int     `json:"children_left"`
+	ChildrenRight []int     `json:"children_right"`
+	Feature       []int     `json:"feature"`
+	Threshold     []float64 `json:"threshold"`
+	NodeSamples   []int     `json:"n_node_samples"`
+}
+
+// Load reads the dump of a model from the file and converts it to a forest.
+func Load(path string) (*iforest.Forest, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return Read(file)
+}
+
+// Read reads the dump of a model and converts it to a forest.
+func Read(r io.Reader) (*iforest.Forest, error) {
+	var m Model
+	if err := json.NewDecoder(r).Decode(&m); err != nil {
+		return nil, err
+	}
+	return m.Forest()
+}
+
+// Forest converts the model to a forest.
+func (m *Model) Forest() (*iforest.Forest, error) {
+	if len(m.Estimators) == 0 {
+		return nil, errors.New("model has no estimators")
+	}
+	if m.MaxSamples < 2 {
+		return nil, fmt.Errorf("invalid max_samples %d", m.MaxSamples)
+	}
+	if m.NbFeatures < 1 {
+		return nil, fmt.Errorf("invalid n_features_in %d", m.NbFeatures)
+	}
+
+	f := iforest.NewForest(len(m.Estimators), m.MaxSamples, m.Contamination)
+	for j := range m.Estimators {
+		t, err := m.tree(j)
+		if err != nil {
+			return nil, fmt.Errorf("estimator %d: %v", j, err)
+		}
+		f.Trees[j] = t
+	}
+	f.CSubSampl = averagePathLength(m.MaxSamples)
+	// the bound of sklearn is kept when the forest is tested again
+	bound := m.Offset + 0.5
+	f.AnomalyBound = bound
+	f.TunedBound = &bound
+	f.NbFeatures = m.NbFeatures
+	f.Trained = true
+	f.Tested = true
+
+	if m.FeatureNames != nil {
+		if len(m.FeatureNames) != m.NbFeatures {
+			return nil, fmt.Errorf("model has %d feature names for %d features", len(m.FeatureNames), m.NbFeatures)
+		}
+		schema, err := iforest.NewSchema(m.FeatureNames, nil)
+		if err != nil {
+			return nil, err
+		}
+		f.Schema = schema
+	}
+	return f, nil
+}
+
+// tree converts the j-th estimator to a tree.
+func (m *Model) tree(j int) (iforest.Tree, error) {
+	e := m.Estimators[j]
+	nbNodes := len(e.ChildrenLeft)
+	if nbNodes == 0 {
+		return iforest.Tree{}, errors.New("tree has no nodes")
+	}
+	if len(e.ChildrenRight) != nbNodes || len(e.Feature) != nbNodes ||
+		len(e.Threshold) != nbNodes || len(e.NodeSamples) != nbNodes {
+		return iforest.Tree{}, errors.New("tree arrays have different lengths")
+	}
+	for _, attribute := range e.Features {
+		if attribute < 0 || attribute >= m.NbFeatures {
+			return iforest.Tree{}, fmt.Errorf("invalid feature %d", attribute)
+		}
+	}
+
+	var convert func(k int) (*iforest.Node, error)
+	convert = func(k int) (*iforest.Node, error) {
+		left, right := e.ChildrenLeft[k], e.ChildrenRight[k]
+		if left < 0 && right < 0 {
+			size := e.NodeSamples[k]
+			return &iforest.Node{External: true, Size: size, C: averagePathLength(size)}, nil
+		}
+		// children follow their parent, which also rules out cycles
+		if left <= k || right <= k || left >= nbNodes || right >= nbNodes {
+			return nil, fmt.Errorf("node %d has invalid children", k)
+		}
+		if e.Feature[k] < 0 || e.Feature[k] >= len(e.Features) {
+			return nil, fmt.Errorf("node %d has invalid feature %d", k, e.Feature[k])
+		}
+		n := &iforest.Node{
+			Attribute: e.Features[e.Feature[k]],
+			Split:     split(e.Threshold[k]),
+			Size:      e.NodeSamples[k],
+		}
+		var err error
+		if n.Left, err = convert(left); err != nil {
+			return nil, err
+		}
+		if n.Right, err = convert(right); err != nil {
+			return nil, err
+		}
+		return n, nil
+	}
+	root, err := convert(0)
+	if err != nil {
+		return iforest.Tree{}, err
+	}
+
+	if root.External {
+		// a tree of a single leaf, trained on identical vectors, becomes a
+		// split leading to the leaf whichever the branch, the adjustment
+		// compensating for the extra edge
+		if root.Size < 2 {
+			return iforest.Tree{}, errors.New("tree is a leaf of a single sample")
+		}
+		root.C--
+		root = &iforest.Node{Left: root, Right: root, Size: root.Size}
+	}
+	return iforest.Tree{Root: root}, nil
+}
+
+// averagePathLength is the average path length of an unsuccessful search in
+// a binary search tree of n nodes, as computed by sklearn: c(2) is 1 while
+// iforest uses the asymptotic formula for all sizes.
+func averagePathLength(n int) float64 {
+	switch {
+	case n <= 1:
+		return 0
+	case n == 2:
+		return 1
+	}
+	x := float64(n)
+	return 2*(math.Log(x-1)+eulerGamma) - 2*(x-1)/x
+}
+
+// split returns the split value s such that, for any x, x < s when
+// float32(x) <= threshold, which is how sklearn sends vectors to the left
+// child.
+func split(threshold float64) float64 {
+	// u is the highest float32 not above the threshold, float32(x) <= u when
+	// x is below the midpoint m between u and the next float32
+	u := float32(threshold)
+	if float64(u) > threshold {
+		u = math.Nextafter32(u, float32(math.Inf(-1)))
+	}
+	v := math.Nextafter32(u, float32(math.Inf(1)))
+	if math.IsInf(float64(v), 1) {
+		// values from half a step above the highest float32 overflow
+		return float64(u) + (float64(u)-float64(math.Nextafter32(u, 0)))/2
+	}
+	m := (float64(u) + float64(v)) / 2
+	// the midpoint itself is rounded to even, to u or v
+	if float64(float32(m)) <= threshold {
+		return math.Nextafter(m, math.Inf(1))
+	}
+	return m
+}
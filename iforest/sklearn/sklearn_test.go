@@ -0,0 +1,179 @@
+package sklearn
+
+import (
+	"encoding/json"
+	"math"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// The fixtures are dumps of isolation forests grown as sklearn does, with the
+// score_samples and predict results of sklearn for some vectors, among which
+// vectors with a feature equal to a threshold.
+type fixture struct {
+	X            [][]float64 `json:"X"`
+	ScoreSamples []float64   `json:"score_samples"`
+	Predict      []int       `json:"predict"`
+}
+
+func TestLoad(t *testing.T) {
+	tests := []struct {
+		name         string
+		featureNames []string
+	}{
+		{name: "isolation_forest", featureNames: []string{"a", "b", "c", "d"}},
+		// a subsampling size of 2, for which c(2) = 1
+		{name: "two_samples"},
+	}
+	for _, tt := range tests {
+
+		f, err := Load(filepath.Join("testdata", tt.name+".json"))
+		if err != nil {
+			t.Errorf("%s: Load() error = %v", tt.name, err)
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join("testdata", tt.name+"_scores.json"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		var want fixture
+		if err := json.Unmarshal(data, &want); err != nil {
+			t.Fatal(err)
+		}
+
+		labels, scores, err := f.Predict(want.X)
+		if err != nil {
+			t.Errorf("%s: Forest.Predict() error = %v", tt.name, err)
+			continue
+		}
+		for i := range want.X {
+			if math.Abs(scores[i]-0.5-want.ScoreSamples[i]) > 1e-12 {
+				t.Errorf("%s: vector %d: score = %v, want score_samples %v + 0.5", tt.name, i, scores[i], want.ScoreSamples[i])
+			}
+			if (labels[i] == 1) != (want.Predict[i] == -1) {
+				t.Errorf("%s: vector %d: label = %v, want predict %v", tt.name, i, labels[i], want.Predict[i])
+			}
+		}
+		bound := f.AnomalyBound
+		if err := f.Test(want.X); err != nil || f.AnomalyBound != bound {
+			t.Errorf("%s: Forest.Test() error = %v, bound = %v, want the sklearn bound %v", tt.name, err, f.AnomalyBound, bound)
+		}
+		var names []string
+		if f.Schema != nil {
+			names = f.Schema.Names()
+		}
+		if !reflect.DeepEqual(names, tt.featureNames) {
+			t.Errorf("%s: Load() feature names = %v, want %v", tt.name, names, tt.featureNames)
+		}
+
+	}
+}
+
+func TestRead_errors(t *testing.T) {
+	tree := `{"features": [0], "children_left": [1, -1, -1], "children_right": [2, -1, -1],
+		"feature": [0, -2, -2], "threshold": [0.5, -2, -2], "n_node_samples": [4, 2, 2]}`
+	tests := []struct {
+		name  string
+		model string
+		want  string
+	}{
+		{name: "valid", model: `{"n_features_in": 1, "max_samples": 4, "estimators": [` + tree + `]}`},
+		{name: "invalid json", model: `{"n_features_in": 1,`, want: "unexpected EOF"},
+		{name: "no estimators", model: `{"n_features_in": 1, "max_samples": 4}`, want: "no estimators"},
+		{name: "one sample", model: `{"n_features_in": 1, "max_samples": 1, "estimators": [` + tree + `]}`, want: "max_samples"},
+		{name: "features", model: `{"n_features_in": 0, "max_samples": 4, "estimators": [` + tree + `]}`, want: "n_features_in"},
+		{
+			name:  "feature names",
+			model: `{"n_features_in": 1, "max_samples": 4, "feature_names": ["a", "b"], "estimators": [` + tree + `]}`,
+			want:  "feature names",
+		},
+		{
+			name:  "feature subset",
+			model: `{"n_features_in": 1, "max_samples": 4, "estimators": [` + strings.Replace(tree, `"features": [0]`, `"features": [1]`, 1) + `]}`,
+			want:  "invalid feature 1",
+		},
+		{
+			name:  "array lengths",
+			model: `{"n_features_in": 1, "max_samples": 4, "estimators": [` + strings.Replace(tree, `[4, 2, 2]`, `[4, 2]`, 1) + `]}`,
+			want:  "different lengths",
+		},
+		{
+			name:  "cycle",
+			model: `{"n_features_in": 1, "max_samples": 4, "estimators": [` + strings.Replace(tree, `[1, -1, -1]`, `[0, -1, -1]`, 1) + `]}`,
+			want:  "invalid children",
+		},
+		{
+			name:  "node feature",
+			model: `{"n_features_in": 1, "max_samples": 4, "estimators": [` + strings.Replace(tree, `[0, -2, -2]`, `[1, -2, -2]`, 1) + `]}`,
+			want:  "invalid feature 1",
+		},
+	}
+	for _, tt := range tests {
+
+		_, err := Read(strings.NewReader(tt.model))
+		if tt.want == "" && err != nil {
+			t.Errorf("%s: Read() error = %v", tt.name, err)
+		}
+		if tt.want != "" && (err == nil || !strings.Contains(err.Error(), tt.want)) {
+			t.Errorf("%s: Read() error = %v, want %q", tt.name, err, tt.want)
+		}
+
+	}
+}
+
+func TestModel_Forest_leaf(t *testing.T) {
+	// sklearn gives a path length of c(3) to the vectors of a tree reduced
+	// to its root
+	m := &Model{NbFeatures: 2, MaxSamples: 3, Estimators: []Estimator{{
+		Features:      []int{0, 1},
+		ChildrenLeft:  []int{-1},
+		ChildrenRight: []int{-1},
+		Feature:       []int{-2},
+		Threshold:     []float64{-2},
+		NodeSamples:   []int{3},
+	}}}
+	f, err := m.Forest()
+	if err != nil {
+		t.Fatalf("Model.Forest() error = %v", err)
+	}
+	for _, x := range [][]float64{{-1, 0}, {1, 0}} {
+		paths, _ := f.PathLengths(x)
+		if math.Abs(paths[0]-averagePathLength(3)) > 1e-12 {
+			t.Errorf("Forest.PathLengths(%v) = %v, want %v", x, paths, averagePathLength(3))
+		}
+	}
+
+	m.Estimators[0].NodeSamples[0] = 1
+	if _, err := m.Forest(); err == nil {
+		t.Errorf("Model.Forest() with a leaf of one sample error = nil, want an error")
+	}
+}
+
+func TestSplit(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	thresholds := []float64{0, 0.1, -0.1, 1, 1.5, 3.4028234663852886e+38, -1e-45}
+	for i := 0; i < 1000; i++ {
+		thresholds = append(thresholds, r.NormFloat64()*math.Pow(10, float64(r.Intn(20)-10)))
+	}
+	for _, threshold := range thresholds {
+		s := split(threshold)
+		// values around the threshold, the split and the float32 midpoints
+		x := threshold
+		for _, base := range []float64{threshold, s} {
+			x = base
+			for k := 0; k < 4; k++ {
+				x = math.Nextafter(x, math.Inf(-1))
+			}
+			for k := 0; k < 8; k++ {
+				if got, want := x < s, float64(float32(x)) <= threshold; got != want {
+					t.Fatalf("split(%v) = %v: %v < split is %v, want %v", threshold, s, x, got, want)
+				}
+				x = math.Nextafter(x, math.Inf(1))
+			}
+		}
+	}
+}
@@ -0,0 +1,70 @@
+package iforest
+
+import (
+	"errors"
+	"sort"
+)
+
+// CSR is a sparse matrix in compressed sparse row format. Only non-zero
+// elements are stored, elements that are absent are equal to zero. It suits
+// high dimensional data with few non-zero attributes per vector.
+//
+// The column indices of row i are Indices[Indptr[i]:Indptr[i+1]] and their
+// values are Data[Indptr[i]:Indptr[i+1]]. Indices of each row must be sorted
+// in increasing order.
+type CSR struct {
+	rows    int
+	cols    int
+	indptr  []int
+	indices []int
+	data    []float64
+}
+
+// NewCSR creates a sparse matrix with given dimensions from its compressed
+// representation. Slices are used directly without copying.
+func NewCSR(rows, cols int, indptr, indices []int, data []float64) (*CSR, error) {
+	if rows < 0 || cols < 0 {
+		return nil, errors.New("matrix dimensions cannot be negative")
+	}
+	if len(indptr) != rows+1 || indptr[0] != 0 {
+		return nil, errors.New("indptr must have rows+1 elements and start with 0")
+	}
+	if len(indices) != len(data) || indptr[rows] != len(data) {
+		return nil, errors.New("indices and data lengths do not match indptr")
+	}
+	for i := 0; i < rows; i++ {
+		if indptr[i] > indptr[i+1] {
+			return nil, errors.New("indptr must be non-decreasing")
+		}
+		for k := indptr[i]; k < indptr[i+1]; k++ {
+			if indices[k] < 0 || indices[k] >= cols {
+				return nil, errors.New("column index out of range")
+			}
+			if k > indptr[i] && indices[k] <= indices[k-1] {
+				return nil, errors.New("column indices must be sorted and unique within a row")
+			}
+		}
+	}
+	return &CSR{rows: rows, cols: cols, indptr: indptr, indices: indices, data: data}, nil
+}
+
+// Dims returns the number of rows and columns of the matrix.
+func (m *CSR) Dims() (int, int) {
+	return m.rows, m.cols
+}
+
+// At returns the value of the element at row i and column j. The value is
+// found with a binary search among the non-zero elements of the row.
+func (m *CSR) At(i, j int) float64 {
+	start, stop := m.indptr[i], m.indptr[i+1]
+	k := start + sort.SearchInts(m.indices[start:stop], j)
+	if k < stop && m.indices[k] == j {
+		return m.data[k]
+	}
+	return 0
+}
+
+// NNZ returns the number of stored elements.
+func (m *CSR) NNZ() int {
+	return len(m.data)
+}
@@ -0,0 +1,128 @@
+package iforest
+
+import (
+	"reflect"
+	"testing"
+)
+
+// denseAndSparse returns the same small dataset in dense and CSR forms.
+func denseAndSparse() ([][]float64, *CSR) {
+	X := [][]float64{
+		{0, 0, 3, 0},
+		{0, 0, 0, 0},
+		{1, 0, 0, -2},
+		{0, 5, 0, 0},
+		{0, 0, 3, 0},
+		{0, 0, 0, 40},
+	}
+	m, _ := NewCSR(6, 4,
+		[]int{0, 1, 1, 3, 4, 5, 6},
+		[]int{2, 0, 3, 1, 2, 3},
+		[]float64{3, 1, -2, 5, 3, 40})
+	return X, m
+}
+
+func TestNewCSR(t *testing.T) {
+	type args struct {
+		rows, cols int
+		indptr     []int
+		indices    []int
+		data       []float64
+	}
+	tests := []struct {
+		args    args
+		wantErr bool
+	}{
+		{args: args{rows: 2, cols: 3, indptr: []int{0, 1, 2}, indices: []int{0, 2}, data: []float64{1, 2}}, wantErr: false},
+		{args: args{rows: 2, cols: 3, indptr: []int{0, 0, 0}, indices: []int{}, data: []float64{}}, wantErr: false},
+		{args: args{rows: 2, cols: 3, indptr: []int{0, 1}, indices: []int{0}, data: []float64{1}}, wantErr: true},
+		{args: args{rows: 2, cols: 3, indptr: []int{0, 1, 2}, indices: []int{0, 3}, data: []float64{1, 2}}, wantErr: true},
+		{args: args{rows: 1, cols: 3, indptr: []int{0, 2}, indices: []int{2, 1}, data: []float64{1, 2}}, wantErr: true},
+		{args: args{rows: 1, cols: 3, indptr: []int{0, 2}, indices: []int{1}, data: []float64{1, 2}}, wantErr: true},
+		{args: args{rows: 2, cols: 3, indptr: []int{0, 2, 1}, indices: []int{0, 1}, data: []float64{1, 2}}, wantErr: true},
+	}
+	for i, tt := range tests {
+
+		if _, err := NewCSR(tt.args.rows, tt.args.cols, tt.args.indptr, tt.args.indices, tt.args.data); (err != nil) != tt.wantErr {
+			t.Errorf("%d. NewCSR() error = %v, wantErr %v", i, err, tt.wantErr)
+		}
+
+	}
+}
+
+func TestCSR_At(t *testing.T) {
+	X, m := denseAndSparse()
+
+	if r, c := m.Dims(); r != len(X) || c != len(X[0]) {
+		t.Errorf("CSR.Dims() = %v, %v, want %v, %v", r, c, len(X), len(X[0]))
+	}
+	for i := range X {
+		for j := range X[i] {
+			if got := m.At(i, j); got != X[i][j] {
+				t.Errorf("CSR.At(%d, %d) = %v, want %v", i, j, got, X[i][j])
+			}
+		}
+	}
+}
+
+func Test_findSplitSparse(t *testing.T) {
+	_, m := denseAndSparse()
+
+	tests := []struct {
+		ind   []int
+		att   int
+		want1 float64
+		want2 float64
+	}{
+		{ind: []int{0, 1, 2, 3, 4, 5}, att: 3, want1: -2, want2: 40},
+		{ind: []int{0, 3}, att: 1, want1: 0, want2: 5},
+		{ind: []int{1, 3}, att: 2, want1: 0, want2: 0},
+	}
+	for _, tt := range tests {
+
+		if got := findSplit(m, tt.ind, tt.att, globalRand); got < tt.want1 || got > tt.want2 {
+			t.Errorf("findSplit() = %v, want between %v : %v", got, tt.want1, tt.want2)
+		}
+
+	}
+}
+
+func Test_splitMatrixSparse(t *testing.T) {
+	X, m := denseAndSparse()
+	ind := []int{0, 1, 2, 3, 4, 5}
+
+	for att := 0; att < len(X[0]); att++ {
+		for _, split := range []float64{-1, 0, 0.5, 2, 10} {
+
+			wantSmaller, wantBigger := splitMatrix(RowSlices(X), split, att, ind)
+			gotSmaller, gotBigger := splitMatrix(m, split, att, ind)
+			if !reflect.DeepEqual(gotSmaller, wantSmaller) || !reflect.DeepEqual(gotBigger, wantBigger) {
+				t.Errorf("splitMatrix() = %v, %v, want %v, %v", gotSmaller, gotBigger, wantSmaller, wantBigger)
+			}
+
+		}
+	}
+}
+
+func TestForest_PredictSparse(t *testing.T) {
+	X, m := denseAndSparse()
+
+	for _, train := range []Matrix{RowSlices(X), m} {
+
+		f := NewForest(20, 4, 0.2)
+		f.TrainMatrix(train)
+		if err := f.TestMatrix(m); err != nil {
+			t.Fatalf("Forest.TestMatrix() error = %v", err)
+		}
+
+		wantLabels, wantScores, _ := f.Predict(X)
+		labels, scores, err := f.PredictMatrix(m)
+		if err != nil {
+			t.Fatalf("Forest.PredictMatrix() error = %v", err)
+		}
+		if !reflect.DeepEqual(labels, wantLabels) || !reflect.DeepEqual(scores, wantScores) {
+			t.Errorf("Forest.PredictMatrix() = %v, %v, want %v, %v", labels, scores, wantLabels, wantScores)
+		}
+
+	}
+}
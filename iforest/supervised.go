@@ -0,0 +1,177 @@
+package iforest
+
+import (
+	"errors"
+	"math"
+	"sort"
+)
+
+// BoundMetrics describes the labelling of a dataset obtained with an anomaly
+// bound, compared to its ground truth.
+type BoundMetrics struct {
+	Bound     float64
+	TP        int
+	FP        int
+	TN        int
+	FN        int
+	Precision float64
+	Recall    float64
+	F1        float64
+	// Value is the value of the objective used to choose the bound.
+	Value float64
+}
+
+// Objective rates the labelling obtained with an anomaly bound, the bound with
+// the highest value is chosen. Labellings which are not acceptable are rejected
+// by returning false.
+type Objective interface {
+	Value(m BoundMetrics) (float64, bool)
+}
+
+// MaxF1 chooses the bound with the highest F1 score.
+type MaxF1 struct{}
+
+// Value implements the Objective interface.
+func (MaxF1) Value(m BoundMetrics) (float64, bool) {
+	return m.F1, true
+}
+
+// PrecisionAtRecall chooses the bound with the highest precision among the
+// ones which detect at least MinRecall of the anomalies.
+type PrecisionAtRecall struct {
+	MinRecall float64
+}
+
+// Value implements the Objective interface.
+func (o PrecisionAtRecall) Value(m BoundMetrics) (float64, bool) {
+	return m.Precision, m.Recall >= o.MinRecall
+}
+
+// MinCost chooses the bound with the lowest total cost of the mistakes, each
+// false positive costing FP and each false negative costing FN. The value of
+// the objective is the opposite of the cost.
+type MinCost struct {
+	FP float64
+	FN float64
+}
+
+// Value implements the Objective interface.
+func (o MinCost) Value(m BoundMetrics) (float64, bool) {
+	return -(o.FP*float64(m.FP) + o.FN*float64(m.FN)), true
+}
+
+// TuneThreshold chooses the anomaly bound which optimizes the objective on
+// scores whose ground truth labels are known, 1 for anomalies and 0 for normal
+// vectors. Every bound separating two distinct scores is considered and the
+// lowest of the best ones is returned with its metrics.
+func TuneThreshold(scores []float64, labels []int, obj Objective) (BoundMetrics, error) {
+	if len(scores) != len(labels) {
+		return BoundMetrics{}, errors.New("cannot tune the threshold - scores and labels lengths differ")
+	}
+	if len(scores) == 0 {
+		return BoundMetrics{}, errors.New("cannot tune the threshold - no scores")
+	}
+
+	ids := make([]int, len(scores))
+	var positives int
+	for i := range ids {
+		ids[i] = i
+		switch labels[i] {
+		case 1:
+			positives++
+		case 0:
+		default:
+			return BoundMetrics{}, errors.New("cannot tune the threshold - labels must be 0 or 1")
+		}
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		return scores[ids[i]] < scores[ids[j]]
+	})
+
+	var best BoundMetrics
+	found := false
+	var tp, fp int
+	for k := 0; k <= len(ids); k++ {
+		// the k lowest scores are labelled as anomalies, bounds are only
+		// placed between distinct scores
+		if k > 0 {
+			if labels[ids[k-1]] == 1 {
+				tp++
+			} else {
+				fp++
+			}
+		}
+		if k > 0 && k < len(ids) && scores[ids[k]] == scores[ids[k-1]] {
+			continue
+		}
+
+		m := newBoundMetrics(tp, fp, positives, len(ids))
+		switch {
+		case k == 0:
+			m.Bound = scores[ids[0]]
+		case k == len(ids):
+			m.Bound = math.Nextafter(scores[ids[k-1]], math.Inf(1))
+		default:
+			m.Bound = (scores[ids[k-1]] + scores[ids[k]]) / 2
+		}
+		value, ok := obj.Value(m)
+		if !ok {
+			continue
+		}
+		m.Value = value
+		if !found || m.Value > best.Value {
+			best = m
+			found = true
+		}
+	}
+	if !found {
+		return BoundMetrics{}, errors.New("cannot tune the threshold - no bound satisfies the objective")
+	}
+	return best, nil
+}
+
+// TuneBound chooses the anomaly bound which optimizes the objective on the
+// labelled dataset, 1 for anomalies and 0 for normal vectors. The bound is kept
+// by following tests and the tested vectors are labelled again. A trained
+// forest which has not been tested yet is marked as tested, so that it
+// predicts with the tuned bound.
+func (f *Forest) TuneBound(X [][]float64, labels []int, obj Objective) (BoundMetrics, error) {
+	if !f.Trained {
+		return BoundMetrics{}, errors.New("cannot tune the threshold - model has not been trained yet")
+	}
+	if err := f.checkAggregation(); err != nil {
+		return BoundMetrics{}, err
+	}
+	scores := make([]float64, len(X))
+	for i := range X {
+		scores[i] = f.score(RowSlices(X), i)
+	}
+	m, err := TuneThreshold(scores, labels, obj)
+	if err != nil {
+		return BoundMetrics{}, err
+	}
+
+	bound := m.Bound
+	f.AnomalyBound = bound
+	f.TunedBound = &bound
+	f.Thresholder = nil
+	f.Tested = true
+	if len(f.AnomalyScores) == len(f.Labels) {
+		f.label(len(f.Labels))
+	}
+	return m, nil
+}
+
+func newBoundMetrics(tp, fp, positives, n int) BoundMetrics {
+	m := BoundMetrics{TP: tp, FP: fp, FN: positives - tp, TN: n - positives - fp}
+	if tp+fp > 0 {
+		m.Precision = float64(tp) / float64(tp+fp)
+	}
+	if positives > 0 {
+		m.Recall = float64(tp) / float64(positives)
+	}
+	if m.Precision+m.Recall > 0 {
+		m.F1 = 2 * m.Precision * m.Recall / (m.Precision + m.Recall)
+	}
+	return m
+}
@@ -0,0 +1,124 @@
+package iforest
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestTuneThreshold(t *testing.T) {
+	scores := []float64{-0.3, -0.2, -0.1, 0, 0.1, 0.2}
+
+	tests := []struct {
+		name      string
+		scores    []float64
+		labels    []int
+		obj       Objective
+		wantBelow int
+		wantValue float64
+		wantErr   bool
+	}{
+		{name: "separable", scores: scores, labels: []int{1, 1, 0, 0, 0, 0}, obj: MaxF1{}, wantBelow: 2, wantValue: 1},
+		{name: "noisy", scores: scores, labels: []int{1, 0, 1, 0, 0, 0}, obj: MaxF1{}, wantBelow: 3, wantValue: 0.8},
+		{name: "recall", scores: scores, labels: []int{1, 0, 1, 0, 0, 1}, obj: PrecisionAtRecall{MinRecall: 0.6}, wantBelow: 3, wantValue: 2.0 / 3},
+		{name: "cost", scores: scores, labels: []int{1, 0, 1, 0, 0, 0}, obj: MinCost{FP: 1, FN: 5}, wantBelow: 3, wantValue: -1},
+		{name: "ties", scores: []float64{-0.1, -0.1, 0, 0}, labels: []int{1, 0, 0, 0}, obj: MaxF1{}, wantBelow: 2, wantValue: 2.0 / 3},
+		{name: "infeasible", scores: scores, labels: []int{0, 0, 0, 0, 0, 0}, obj: PrecisionAtRecall{MinRecall: 0.5}, wantErr: true},
+		{name: "lengths", scores: scores, labels: []int{1}, obj: MaxF1{}, wantErr: true},
+		{name: "labels", scores: scores, labels: []int{1, 1, 0, 0, 0, -1}, obj: MaxF1{}, wantErr: true},
+		{name: "empty", scores: nil, labels: nil, obj: MaxF1{}, wantErr: true},
+	}
+	for _, tt := range tests {
+
+		got, err := TuneThreshold(tt.scores, tt.labels, tt.obj)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: TuneThreshold() error = %v, wantErr %v", tt.name, err, tt.wantErr)
+			continue
+		}
+		if err != nil {
+			continue
+		}
+		if below := countBelow(tt.scores, got.Bound); below != tt.wantBelow {
+			t.Errorf("%s: TuneThreshold() = %v, %v scores below, want %v", tt.name, got.Bound, below, tt.wantBelow)
+		}
+		if got.Value != tt.wantValue {
+			t.Errorf("%s: TuneThreshold() value = %v, want %v", tt.name, got.Value, tt.wantValue)
+		}
+		if got.TP+got.FP != tt.wantBelow || got.TP+got.FP+got.TN+got.FN != len(tt.scores) {
+			t.Errorf("%s: TuneThreshold() confusion = %+v", tt.name, got)
+		}
+
+	}
+}
+
+func TestForest_TuneBound(t *testing.T) {
+	X := [][]float64{{1, 1}, {1, 2}, {2, 1}, {2, 2}, {1, 1}, {10, 10}, {2, 1}, {-8, 9}}
+	labels := []int{0, 0, 0, 0, 0, 1, 0, 1}
+
+	f := NewForest(50, 8, 0.5)
+	if _, err := f.TuneBound(X, labels, MaxF1{}); err == nil {
+		t.Errorf("Forest.TuneBound() should fail before training")
+	}
+	f.Train(X)
+
+	// an untested forest predicts with the tuned bound
+	m, err := f.TuneBound(X, labels, MaxF1{})
+	if err != nil {
+		t.Fatalf("Forest.TuneBound() before testing error = %v", err)
+	}
+	if _, scores, err := f.Predict(X); err != nil || f.AnomalyBound != m.Bound || len(scores) != len(X) {
+		t.Errorf("Forest.Predict() after Forest.TuneBound() error = %v, bound = %v, want %v", err, f.AnomalyBound, m.Bound)
+	}
+	f.Test(X)
+
+	m, err = f.TuneBound(X, labels, MaxF1{})
+	if err != nil {
+		t.Fatalf("Forest.TuneBound() error = %v", err)
+	}
+	if f.AnomalyBound != m.Bound {
+		t.Errorf("Forest.TuneBound() bound = %v, want %v", f.AnomalyBound, m.Bound)
+	}
+
+	predicted, _, _ := f.Predict(X)
+	var tp int
+	for i := range predicted {
+		if predicted[i] != f.Labels[i] {
+			t.Errorf("Forest.TuneBound() labels = %v, want %v", f.Labels, predicted)
+			break
+		}
+		if predicted[i] == 1 && labels[i] == 1 {
+			tp++
+		}
+	}
+	if tp != m.TP {
+		t.Errorf("Forest.TuneBound() reports %v true positives, Predict gives %v", m.TP, tp)
+	}
+
+	f.Test(X)
+	if f.AnomalyBound != m.Bound {
+		t.Errorf("Forest.Test() bound = %v, want the tuned bound %v to be kept", f.AnomalyBound, m.Bound)
+	}
+
+	// the tuned bound is saved with the model
+	path := filepath.Join(t.TempDir(), "tuned.json")
+	if err := f.Save(path); err != nil {
+		t.Fatal(err)
+	}
+	loaded := &Forest{}
+	if err := loaded.Load(path); err != nil {
+		t.Fatal(err)
+	}
+	loaded.Test(X)
+	if loaded.AnomalyBound != m.Bound {
+		t.Errorf("Forest.Test() after loading bound = %v, want the tuned bound %v to be kept", loaded.AnomalyBound, m.Bound)
+	}
+
+	// another strategy replaces it
+	if err := loaded.SetContamination(0.5); err != nil {
+		t.Fatal(err)
+	}
+	bound := loaded.AnomalyBound
+	loaded.Test(X)
+	if loaded.TunedBound != nil || loaded.AnomalyBound != bound {
+		t.Errorf("Forest.Test() after SetContamination() bound = %v, want the contamination bound %v", loaded.AnomalyBound, bound)
+	}
+}
@@ -0,0 +1,280 @@
+package iforest
+
+import (
+	"errors"
+	"math"
+	"sort"
+)
+
+// Thresholder chooses the anomaly bound from the anomaly scores of the tested
+// dataset. Vectors with a score lower than the bound are labelled as anomalies.
+type Thresholder interface {
+	Threshold(scores []float64) (float64, error)
+}
+
+// Contamination labels the given ratio of the scores as anomalies. The bound
+// lies between the scores at the floor and the ceiling of ratio*n. This is the
+// default strategy, used with Forest.AnomalyRatio.
+type Contamination float64
+
+// Threshold implements the Thresholder interface.
+func (r Contamination) Threshold(scores []float64) (float64, error) {
+	if len(scores) == 0 {
+		return 0, errors.New("cannot choose the anomaly bound - no scores")
+	}
+	if r < 0 || r > 1 {
+		return 0, errors.New("contamination ratio must be between 0 and 1")
+	}
+	sorted := sortedCopy(scores)
+	return contaminationBound(len(sorted), float64(r), func(rank float64) float64 {
+		return sorted[int(rank)]
+	}), nil
+}
+
+// FixedScore uses the given score as the anomaly bound.
+type FixedScore float64
+
+// Threshold implements the Thresholder interface.
+func (s FixedScore) Threshold(scores []float64) (float64, error) {
+	return float64(s), nil
+}
+
+// PaperRule labels as anomalies the vectors with an anomaly score higher than
+// 0.5 as defined in the algorithm specification. Scores of this package are
+// shifted by 0.5, so the bound is 0.
+type PaperRule struct{}
+
+// Threshold implements the Thresholder interface.
+func (PaperRule) Threshold(scores []float64) (float64, error) {
+	return 0, nil
+}
+
+// IQRFence places the bound at the lower Tukey fence of the scores: Q1 - K*IQR,
+// where IQR is the interquartile range. K defaults to 1.5.
+type IQRFence struct {
+	K float64
+}
+
+// Threshold implements the Thresholder interface.
+func (t IQRFence) Threshold(scores []float64) (float64, error) {
+	if len(scores) == 0 {
+		return 0, errors.New("cannot choose the anomaly bound - no scores")
+	}
+	k := t.K
+	if k == 0 {
+		k = 1.5
+	}
+	sorted := sortedCopy(scores)
+	q1, q3 := quantile(sorted, 0.25), quantile(sorted, 0.75)
+	return q1 - k*(q3-q1), nil
+}
+
+// MADFence places the bound K scaled median absolute deviations below the
+// median of the scores. The deviation is scaled by 1.4826 to be consistent with
+// the standard deviation of normally distributed scores. K defaults to 3.
+type MADFence struct {
+	K float64
+}
+
+// Threshold implements the Thresholder interface.
+func (t MADFence) Threshold(scores []float64) (float64, error) {
+	if len(scores) == 0 {
+		return 0, errors.New("cannot choose the anomaly bound - no scores")
+	}
+	k := t.K
+	if k == 0 {
+		k = 3
+	}
+	sorted := sortedCopy(scores)
+	median := quantile(sorted, 0.5)
+	deviations := make([]float64, len(sorted))
+	for i, s := range sorted {
+		deviations[i] = math.Abs(s - median)
+	}
+	sort.Float64s(deviations)
+	return median - k*1.4826*quantile(deviations, 0.5), nil
+}
+
+// Otsu separates the scores in two classes by maximizing the variance between
+// them on a histogram with the given number of bins (256 by default). The
+// lower class is labelled as anomalies.
+type Otsu struct {
+	Bins int
+}
+
+// Threshold implements the Thresholder interface.
+func (t Otsu) Threshold(scores []float64) (float64, error) {
+	if len(scores) == 0 {
+		return 0, errors.New("cannot choose the anomaly bound - no scores")
+	}
+	bins := t.Bins
+	if bins <= 0 {
+		bins = 256
+	}
+	min, max := minMax(scores)
+	if min == max {
+		return 0, errors.New("cannot choose the anomaly bound - all scores are equal")
+	}
+	width := (max - min) / float64(bins)
+
+	hist := make([]float64, bins)
+	for _, s := range scores {
+		hist[binIndex(s, min, width, bins)]++
+	}
+	// scores are represented by the center of their bin, in the means of
+	// both classes
+	var total float64
+	for i := range hist {
+		total += hist[i] * (min + (float64(i)+0.5)*width)
+	}
+
+	n := float64(len(scores))
+	var count, sum, bestVariance float64
+	best := 0
+	for i := 0; i < bins-1; i++ {
+		count += hist[i]
+		sum += hist[i] * (min + (float64(i)+0.5)*width)
+		if count == 0 || count == n {
+			continue
+		}
+		meanLow := sum / count
+		meanHigh := (total - sum) / (n - count)
+		variance := count * (n - count) * (meanLow - meanHigh) * (meanLow - meanHigh)
+		if variance > bestVariance {
+			bestVariance = variance
+			best = i
+		}
+	}
+	return min + float64(best+1)*width, nil
+}
+
+// KDEValley estimates the density of the scores with a gaussian kernel and
+// places the bound at the deepest valley of the density below its highest
+// mode. Only valleys lower than half of a peak found below them, itself higher
+// than 1% of the mode, are considered. Bandwidth defaults to the Silverman's
+// rule of thumb and the density is evaluated at Points locations (512 by
+// default). An error is returned when the density has no such valley.
+type KDEValley struct {
+	Bandwidth float64
+	Points    int
+}
+
+// Threshold implements the Thresholder interface.
+func (t KDEValley) Threshold(scores []float64) (float64, error) {
+	if len(scores) < 2 {
+		return 0, errors.New("cannot choose the anomaly bound - not enough scores")
+	}
+	points := t.Points
+	if points <= 2 {
+		points = 512
+	}
+	h := t.Bandwidth
+	if h <= 0 {
+		h = silverman(sortedCopy(scores))
+	}
+	if h <= 0 {
+		return 0, errors.New("cannot choose the anomaly bound - all scores are equal")
+	}
+
+	min, max := minMax(scores)
+	min -= 3 * h
+	max += 3 * h
+	step := (max - min) / float64(points-1)
+	density := make([]float64, points)
+	for i := range density {
+		x := min + float64(i)*step
+		for _, s := range scores {
+			u := (x - s) / h
+			density[i] += math.Exp(-u * u / 2)
+		}
+	}
+
+	mode := 0
+	for i := range density {
+		if density[i] > density[mode] {
+			mode = i
+		}
+	}
+	valley := -1
+	peak := density[0]
+	for i := 1; i < mode; i++ {
+		if density[i] <= density[i-1] && density[i] < density[i+1] &&
+			peak >= 0.01*density[mode] && density[i] <= 0.5*peak {
+			if valley < 0 || density[i] < density[valley] {
+				valley = i
+			}
+		}
+		peak = math.Max(peak, density[i])
+	}
+	if valley < 0 {
+		return 0, errors.New("cannot choose the anomaly bound - density has no valley")
+	}
+	return min + float64(valley)*step, nil
+}
+
+// FitThreshold chooses a new anomaly bound with the given strategy from the
+// anomaly scores computed during the testing stage and labels the tested
+// vectors again. The strategy is kept for following tests.
+func (f *Forest) FitThreshold(t Thresholder) error {
+	if !f.Tested {
+		return errors.New("cannot fit the threshold - model has not been tested yet")
+	}
+	f.Thresholder = t
+	f.TunedBound = nil
+	return f.computeBound(len(f.AnomalyScores))
+}
+
+// sortedCopy returns the scores sorted in increasing order without modifying
+// the given slice.
+func sortedCopy(scores []float64) []float64 {
+	sorted := make([]float64, len(scores))
+	copy(sorted, scores)
+	sort.Float64s(sorted)
+	return sorted
+}
+
+// quantile computes the q-th quantile of sorted values with linear
+// interpolation between closest ranks.
+func quantile(sorted []float64, q float64) float64 {
+	pos := q * float64(len(sorted)-1)
+	lo := int(math.Floor(pos))
+	hi := int(math.Ceil(pos))
+	return sorted[lo] + (sorted[hi]-sorted[lo])*(pos-float64(lo))
+}
+
+func minMax(values []float64) (float64, float64) {
+	min, max := math.Inf(1), math.Inf(-1)
+	for _, v := range values {
+		min = math.Min(min, v)
+		max = math.Max(max, v)
+	}
+	return min, max
+}
+
+func binIndex(v, min, width float64, bins int) int {
+	i := int((v - min) / width)
+	if i >= bins {
+		i = bins - 1
+	}
+	return i
+}
+
+// silverman computes the Silverman's rule of thumb bandwidth for a gaussian
+// kernel.
+func silverman(sorted []float64) float64 {
+	n := float64(len(sorted))
+	var mean, variance float64
+	for _, s := range sorted {
+		mean += s
+	}
+	mean /= n
+	for _, s := range sorted {
+		variance += (s - mean) * (s - mean)
+	}
+	std := math.Sqrt(variance / (n - 1))
+	spread := std
+	if iqr := (quantile(sorted, 0.75) - quantile(sorted, 0.25)) / 1.34; iqr > 0 && iqr < spread {
+		spread = iqr
+	}
+	return 0.9 * spread * math.Pow(n, -0.2)
+}
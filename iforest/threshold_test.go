@@ -0,0 +1,180 @@
+package iforest
+
+import (
+	"math/rand"
+	"testing"
+)
+
+// bimodalScores returns scores of normal vectors around 0.1 followed by
+// scores of anomalies around -0.2.
+func bimodalScores(normal, anomalies int) []float64 {
+	r := rand.New(rand.NewSource(1))
+	scores := make([]float64, 0, normal+anomalies)
+	for i := 0; i < normal; i++ {
+		scores = append(scores, 0.1+0.02*r.NormFloat64())
+	}
+	for i := 0; i < anomalies; i++ {
+		scores = append(scores, -0.2+0.02*r.NormFloat64())
+	}
+	return scores
+}
+
+// evenScores returns n evenly spaced scores, which have no density valley.
+func evenScores(n int) []float64 {
+	scores := make([]float64, n)
+	for i := range scores {
+		scores[i] = float64(i) / float64(n)
+	}
+	return scores
+}
+
+func countBelow(scores []float64, bound float64) int {
+	var n int
+	for _, s := range scores {
+		if s < bound {
+			n++
+		}
+	}
+	return n
+}
+
+func TestContamination_Threshold(t *testing.T) {
+	scores := []float64{0.3, -0.1, 0.2, 0.1, 0}
+
+	tests := []struct {
+		r         Contamination
+		scores    []float64
+		wantBelow int
+		wantErr   bool
+	}{
+		{r: 0, scores: scores, wantBelow: 0},
+		{r: 0.2, scores: scores, wantBelow: 1},
+		{r: 0.4, scores: scores, wantBelow: 2},
+		{r: 0.5, scores: scores, wantBelow: 3},
+		{r: 0.9, scores: scores, wantBelow: 5},
+		{r: 1, scores: scores, wantBelow: 5},
+		{r: 1.1, scores: scores, wantErr: true},
+		{r: -0.1, scores: scores, wantErr: true},
+		{r: 0.1, scores: nil, wantErr: true},
+	}
+	for _, tt := range tests {
+
+		got, err := tt.r.Threshold(tt.scores)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("Contamination(%v).Threshold() error = %v, wantErr %v", tt.r, err, tt.wantErr)
+			continue
+		}
+		if err == nil && countBelow(tt.scores, got) != tt.wantBelow {
+			t.Errorf("Contamination(%v).Threshold() = %v, %v scores below, want %v", tt.r, got, countBelow(tt.scores, got), tt.wantBelow)
+		}
+
+	}
+}
+
+func TestThresholder_Threshold(t *testing.T) {
+	scores := bimodalScores(950, 50)
+
+	tests := []struct {
+		name string
+		t    Thresholder
+		min  float64
+		max  float64
+	}{
+		{name: "Contamination", t: Contamination(0.05), min: -0.15, max: 0.05},
+		{name: "FixedScore", t: FixedScore(-0.1), min: -0.1, max: -0.1},
+		{name: "PaperRule", t: PaperRule{}, min: 0, max: 0},
+		{name: "IQRFence", t: IQRFence{}, min: -0.15, max: 0.05},
+		{name: "MADFence", t: MADFence{K: 3}, min: -0.15, max: 0.05},
+		{name: "Otsu", t: Otsu{}, min: -0.17, max: 0.05},
+		{name: "KDEValley", t: KDEValley{}, min: -0.15, max: 0.05},
+	}
+	for _, tt := range tests {
+
+		got, err := tt.t.Threshold(scores)
+		if err != nil {
+			t.Errorf("%s.Threshold() error = %v", tt.name, err)
+			continue
+		}
+		if got < tt.min || got > tt.max {
+			t.Errorf("%s.Threshold() = %v, want between %v : %v", tt.name, got, tt.min, tt.max)
+		}
+
+	}
+}
+
+func TestOtsu_Threshold(t *testing.T) {
+	// same histogram, scores at the centers or away from the centers of
+	// their bins
+	tests := []struct {
+		name   string
+		scores []float64
+	}{
+		{name: "centered", scores: []float64{0, 4, 3.5, 2.5, 1.5, 2.5, 1.5}},
+		{name: "off-center", scores: []float64{0, 4, 3.95, 2.95, 1.05, 2.95, 1.95}},
+	}
+	for _, tt := range tests {
+
+		got, err := Otsu{Bins: 4}.Threshold(tt.scores)
+		if err != nil || got != 2 {
+			t.Errorf("%s: Otsu.Threshold() = %v, %v, want 2", tt.name, got, err)
+		}
+
+	}
+}
+
+func TestThresholder_ThresholdErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		t      Thresholder
+		scores []float64
+	}{
+		{name: "IQRFence", t: IQRFence{}, scores: nil},
+		{name: "MADFence", t: MADFence{}, scores: nil},
+		{name: "Otsu", t: Otsu{}, scores: nil},
+		{name: "Otsu", t: Otsu{}, scores: []float64{1, 1, 1}},
+		{name: "KDEValley", t: KDEValley{}, scores: []float64{1}},
+		{name: "KDEValley", t: KDEValley{}, scores: []float64{1, 1, 1}},
+		{name: "KDEValley", t: KDEValley{}, scores: evenScores(1000)},
+	}
+	for _, tt := range tests {
+
+		if _, err := tt.t.Threshold(tt.scores); err == nil {
+			t.Errorf("%s.Threshold(%v scores) should fail", tt.name, len(tt.scores))
+		}
+
+	}
+}
+
+func TestForest_FitThreshold(t *testing.T) {
+	X := [][]float64{{1, 1}, {1, 2}, {2, 1}, {2, 2}, {1, 1}, {10, 10}}
+
+	f := NewForest(10, 4, 1)
+	if err := f.FitThreshold(PaperRule{}); err == nil {
+		t.Errorf("Forest.FitThreshold() should fail before testing")
+	}
+	f.Train(X)
+	if err := f.Test(X); err != nil {
+		t.Fatalf("Forest.Test() error = %v", err)
+	}
+	for i, l := range f.Labels {
+		if l != 1 {
+			t.Errorf("Forest.Test() label %d = %v, want 1 with ratio 1", i, l)
+		}
+	}
+
+	if err := f.FitThreshold(FixedScore(-1)); err != nil {
+		t.Fatalf("Forest.FitThreshold() error = %v", err)
+	}
+	if f.AnomalyBound != -1 {
+		t.Errorf("Forest.FitThreshold() bound = %v, want -1", f.AnomalyBound)
+	}
+	for i, l := range f.Labels {
+		if l != 0 {
+			t.Errorf("Forest.FitThreshold() label %d = %v, want 0", i, l)
+		}
+	}
+
+	if err := f.Test(X); err != nil || f.AnomalyBound != -1 {
+		t.Errorf("Forest.Test() bound = %v, error = %v, want the fitted thresholder to be kept", f.AnomalyBound, err)
+	}
+}
@@ -0,0 +1,352 @@
+// Package timeseries detects anomalies in univariate or multivariate time
+// series with an isolation forest.
+//
+// Series are turned into shingles: the feature vector of a window holds the
+// values of all variables at its consecutive points, after an optional
+// differencing, followed by the values one or more seasons before its last
+// point. A forest trained on the windows of a series scores the windows of
+// the same or of another series, and a Stream scores new points as they
+// arrive.
+package timeseries
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"time"
+
+	"github.com/e-XpertSolutions/go-iforest/v2/iforest"
+)
+
+// Series is a time series. Values[t] holds the values of the variables at
+// Times[t].
+type Series struct {
+	Times  []time.Time
+	Values [][]float64
+}
+
+// Univariate returns the series of a single variable.
+func Univariate(times []time.Time, values []float64) Series {
+	s := Series{Times: times, Values: make([][]float64, len(values))}
+	for t, v := range values {
+		s.Values[t] = []float64{v}
+	}
+	return s
+}
+
+// Config describes the windows of a series.
+type Config struct {
+	// Window is the number of consecutive points of a window.
+	Window int
+	// Stride is the number of points between the ends of two consecutive
+	// windows, 1 when 0.
+	Stride int
+	// Differencing is the number of times the series is differenced, values
+	// replaced by their change since the previous point, before windowing.
+	Differencing int
+	// SeasonalLags are numbers of points, typically seasons. The values at
+	// these lags before the last point of a window are added to its
+	// features.
+	SeasonalLags []int
+}
+
+func (c Config) check() error {
+	if c.Window < 1 {
+		return errors.New("window must have at least one point")
+	}
+	if c.Stride < 0 || c.Differencing < 0 {
+		return errors.New("stride and differencing must not be negative")
+	}
+	for _, lag := range c.SeasonalLags {
+		if lag < 1 {
+			return fmt.Errorf("invalid seasonal lag %d", lag)
+		}
+	}
+	return nil
+}
+
+// History returns the number of points needed to build the first window.
+func (c Config) History() int {
+	back := c.Window - 1
+	for _, lag := range c.SeasonalLags {
+		if lag > back {
+			back = lag
+		}
+	}
+	return c.Differencing + back + 1
+}
+
+// Shingle returns the feature vectors of the windows of a series of values
+// and the index of the last point of each window.
+func (c Config) Shingle(values [][]float64) ([][]float64, []int, error) {
+	if err := c.check(); err != nil {
+		return nil, nil, err
+	}
+	if len(values) == 0 {
+		return nil, nil, errors.New("empty series")
+	}
+	nbVariables := len(values[0])
+	for t := range values {
+		if len(values[t]) != nbVariables {
+			return nil, nil, fmt.Errorf("point %d has %d variables, want %d", t, len(values[t]), nbVariables)
+		}
+		for _, v := range values[t] {
+			if math.IsNaN(v) {
+				return nil, nil, fmt.Errorf("point %d has a missing value", t)
+			}
+		}
+	}
+
+	// differenced[t] is the differenced value at point t+Differencing
+	differenced := values
+	for d := 0; d < c.Differencing && len(differenced) > 0; d++ {
+		next := make([][]float64, len(differenced)-1)
+		for t := range next {
+			next[t] = make([]float64, nbVariables)
+			for j := range next[t] {
+				next[t][j] = differenced[t+1][j] - differenced[t][j]
+			}
+		}
+		differenced = next
+	}
+
+	stride := c.Stride
+	if stride == 0 {
+		stride = 1
+	}
+	var X [][]float64
+	var ends []int
+	for end := c.History() - 1; end < len(values); end += stride {
+		e := end - c.Differencing
+		x := make([]float64, 0, (c.Window+len(c.SeasonalLags))*nbVariables)
+		for t := e - c.Window + 1; t <= e; t++ {
+			x = append(x, differenced[t]...)
+		}
+		for _, lag := range c.SeasonalLags {
+			x = append(x, differenced[e-lag]...)
+		}
+		X = append(X, x)
+		ends = append(ends, end)
+	}
+	if len(X) == 0 {
+		return nil, nil, fmt.Errorf("series has %d points, want at least %d", len(values), c.History())
+	}
+	return X, ends, nil
+}
+
+// Window is the score of a window of a series.
+type Window struct {
+	// Start and End are the times of the first and of the last points of
+	// the window, Index is the index of the last one.
+	Start time.Time
+	End   time.Time
+	Index int
+	Score float64
+	// Label is 1 for anomalies and 0 for normal windows.
+	Label int
+}
+
+// Point is the score of a point of a series.
+type Point struct {
+	Time  time.Time
+	Score float64
+	Label int
+}
+
+// Detector scores the windows of series with a forest.
+type Detector struct {
+	Config
+	Forest *iforest.Forest
+	// NbVariables is the number of variables of the series.
+	NbVariables int
+}
+
+// NewDetector initializes a detector building windows as configured and
+// scoring them with given forest.
+func NewDetector(c Config, forest *iforest.Forest) *Detector {
+	return &Detector{Config: c, Forest: forest}
+}
+
+// Fit trains and tests the forest on the windows of the series.
+func (d *Detector) Fit(s Series) error {
+	if d.Forest == nil {
+		return errors.New("cannot fit - detector has no forest")
+	}
+	if err := checkSeries(s); err != nil {
+		return fmt.Errorf("cannot fit - %v", err)
+	}
+	X, _, err := d.Shingle(s.Values)
+	if err != nil {
+		return fmt.Errorf("cannot fit - %v", err)
+	}
+	d.NbVariables = len(s.Values[0])
+	d.Forest.Train(X)
+	return d.Forest.Test(X)
+}
+
+// Score scores the windows of the series.
+func (d *Detector) Score(s Series) ([]Window, error) {
+	if d.Forest == nil || d.NbVariables == 0 {
+		return nil, errors.New("cannot score - detector has not been fitted yet")
+	}
+	if err := checkSeries(s); err != nil {
+		return nil, err
+	}
+	if len(s.Values) > 0 && len(s.Values[0]) != d.NbVariables {
+		return nil, fmt.Errorf("series has %d variables, want %d", len(s.Values[0]), d.NbVariables)
+	}
+	X, ends, err := d.Shingle(s.Values)
+	if err != nil {
+		return nil, err
+	}
+	labels, scores, err := d.Forest.Predict(X)
+	if err != nil {
+		return nil, err
+	}
+
+	windows := make([]Window, len(X))
+	for i, end := range ends {
+		windows[i] = Window{
+			Start: s.Times[end-d.Window+1],
+			End:   s.Times[end],
+			Index: end,
+			Score: scores[i],
+			Label: labels[i],
+		}
+	}
+	return windows, nil
+}
+
+// ScorePoints scores the windows of the series and gives each point the
+// lowest score, the most anomalous, of the windows it belongs to. Points
+// before the first window are not scored.
+func (d *Detector) ScorePoints(s Series) ([]Point, error) {
+	windows, err := d.Score(s)
+	if err != nil {
+		return nil, err
+	}
+	first := windows[0].Index - d.Window + 1
+	last := windows[len(windows)-1].Index
+	points := make([]Point, last-first+1)
+	for i := range points {
+		points[i] = Point{Time: s.Times[first+i], Score: math.Inf(1)}
+	}
+	for _, w := range windows {
+		for t := w.Index - d.Window + 1; t <= w.Index; t++ {
+			if p := &points[t-first]; w.Score < p.Score {
+				p.Score = w.Score
+			}
+		}
+	}
+
+	// points between windows of a large stride belong to none
+	scored := points[:0]
+	for _, p := range points {
+		if !math.IsInf(p.Score, 1) {
+			if p.Score < d.Forest.AnomalyBound {
+				p.Label = 1
+			}
+			scored = append(scored, p)
+		}
+	}
+	return scored, nil
+}
+
+// Save saves the detector and its forest in the file.
+func (d *Detector) Save(path string) error {
+	file, err := os.Create(path)
+	if err == nil {
+		encoder := json.NewEncoder(file)
+		err = encoder.Encode(d)
+	}
+	file.Close()
+	return err
+}
+
+// Load loads the detector and its forest from the file.
+func (d *Detector) Load(path string) error {
+	file, err := os.Open(path)
+	if err == nil {
+		d.Forest = &iforest.Forest{AnomalyScores: make(map[int]float64)}
+		decoder := json.NewDecoder(file)
+		err = decoder.Decode(d)
+	}
+	file.Close()
+	return err
+}
+
+func checkSeries(s Series) error {
+	if len(s.Times) != len(s.Values) {
+		return fmt.Errorf("series has %d times and %d points", len(s.Times), len(s.Values))
+	}
+	return nil
+}
+
+// Stream scores the points of a series as they arrive, as Score would score
+// the whole series.
+type Stream struct {
+	d      *Detector
+	times  []time.Time
+	values [][]float64
+	// count is the number of points pushed.
+	count int
+}
+
+// NewStream returns a stream scoring new points with the detector, which
+// must have been fitted.
+func (d *Detector) NewStream() (*Stream, error) {
+	if d.Forest == nil || !d.Forest.Trained || !d.Forest.Tested {
+		return nil, errors.New("cannot stream - detector has not been fitted yet")
+	}
+	if err := d.check(); err != nil {
+		return nil, err
+	}
+	return &Stream{d: d}, nil
+}
+
+// Push adds a point to the stream. It returns the window ending with the
+// point and true when one is scored at this point, once enough points have
+// been pushed and according to the stride.
+func (s *Stream) Push(t time.Time, values []float64) (Window, bool, error) {
+	if len(values) != s.d.NbVariables {
+		return Window{}, false, fmt.Errorf("point has %d variables, want %d", len(values), s.d.NbVariables)
+	}
+	for _, v := range values {
+		if math.IsNaN(v) {
+			return Window{}, false, errors.New("point has a missing value")
+		}
+	}
+	history := s.d.History()
+	s.times = append(s.times, t)
+	s.values = append(s.values, append([]float64(nil), values...))
+	if len(s.values) > history {
+		s.times = s.times[1:]
+		s.values = s.values[1:]
+	}
+	s.count++
+
+	stride := s.d.Stride
+	if stride == 0 {
+		stride = 1
+	}
+	if len(s.values) < history || (s.count-history)%stride != 0 {
+		return Window{}, false, nil
+	}
+	X, _, err := s.d.Shingle(s.values)
+	if err != nil {
+		return Window{}, false, err
+	}
+	labels, scores, err := s.d.Forest.Predict(X)
+	if err != nil {
+		return Window{}, false, err
+	}
+	return Window{
+		Start: s.times[history-s.d.Window],
+		End:   t,
+		Index: s.count - 1,
+		Score: scores[0],
+		Label: labels[0],
+	}, true, nil
+}
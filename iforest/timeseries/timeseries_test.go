@@ -0,0 +1,177 @@
+package timeseries
+
+import (
+	"math"
+	"math/rand"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/e-XpertSolutions/go-iforest/v2/iforest"
+)
+
+func TestConfig_Shingle(t *testing.T) {
+	// squares, their first differences are odd numbers
+	values := make([][]float64, 8)
+	for t := range values {
+		values[t] = []float64{float64(t * t), float64(-t)}
+	}
+	tests := []struct {
+		name     string
+		config   Config
+		values   [][]float64
+		want     [][]float64
+		wantEnds []int
+		wantErr  bool
+	}{
+		{
+			name:     "window",
+			config:   Config{Window: 2, Stride: 3},
+			values:   values,
+			want:     [][]float64{{0, 0, 1, -1}, {9, -3, 16, -4}, {36, -6, 49, -7}},
+			wantEnds: []int{1, 4, 7},
+		},
+		{
+			name:     "differencing and seasonal lag",
+			config:   Config{Window: 2, Stride: 2, Differencing: 1, SeasonalLags: []int{3}},
+			values:   values,
+			want:     [][]float64{{5, -1, 7, -1, 1, -1}, {9, -1, 11, -1, 5, -1}},
+			wantEnds: []int{4, 6},
+		},
+		{
+			name:     "second order differencing",
+			config:   Config{Window: 1, Differencing: 2},
+			values:   values[:4],
+			want:     [][]float64{{2, 0}, {2, 0}},
+			wantEnds: []int{2, 3},
+		},
+		{name: "no window", config: Config{}, values: values, wantErr: true},
+		{name: "negative lag", config: Config{Window: 1, SeasonalLags: []int{-1}}, values: values, wantErr: true},
+		{name: "too short", config: Config{Window: 9}, values: values, wantErr: true},
+		{name: "ragged", config: Config{Window: 1}, values: [][]float64{{1}, {2, 3}}, wantErr: true},
+		{name: "missing value", config: Config{Window: 1}, values: [][]float64{{1}, {math.NaN()}}, wantErr: true},
+	}
+	for _, tt := range tests {
+
+		X, ends, err := tt.config.Shingle(tt.values)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: Config.Shingle() error = %v, wantErr %v", tt.name, err, tt.wantErr)
+			continue
+		}
+		if !reflect.DeepEqual(X, tt.want) || !reflect.DeepEqual(ends, tt.wantEnds) {
+			t.Errorf("%s: Config.Shingle() = %v %v, want %v %v", tt.name, X, ends, tt.want, tt.wantEnds)
+		}
+
+	}
+}
+
+// series returns a daily seasonal series of hourly points, with a spike at
+// given index if not negative.
+func series(n, spike int, seed int64) Series {
+	r := rand.New(rand.NewSource(seed))
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	times := make([]time.Time, n)
+	values := make([]float64, n)
+	for t := range values {
+		times[t] = start.Add(time.Duration(t) * time.Hour)
+		values[t] = 10*math.Sin(2*math.Pi*float64(t)/24) + r.NormFloat64()*0.3
+		if t == spike {
+			values[t] += 8
+		}
+	}
+	return Univariate(times, values)
+}
+
+func TestDetector(t *testing.T) {
+	f := iforest.NewForest(100, 256, 0.01)
+	f.Seed = 1
+	d := NewDetector(Config{Window: 4, Differencing: 1, SeasonalLags: []int{24}}, f)
+	if _, err := d.Score(series(100, -1, 2)); err == nil {
+		t.Errorf("Detector.Score() before fitting error = nil, want an error")
+	}
+	if _, err := d.NewStream(); err == nil {
+		t.Errorf("Detector.NewStream() before fitting error = nil, want an error")
+	}
+	if err := d.Fit(series(24*30, -1, 1)); err != nil {
+		t.Fatalf("Detector.Fit() error = %v", err)
+	}
+
+	s := series(24*5, 70, 2)
+	windows, err := d.Score(s)
+	if err != nil {
+		t.Fatalf("Detector.Score() error = %v", err)
+	}
+	if len(windows) != 24*5-25 || windows[0].Index != 25 || !windows[0].End.Equal(s.Times[25]) || !windows[0].Start.Equal(s.Times[22]) {
+		t.Fatalf("Detector.Score() = %v windows, first %+v", len(windows), windows[0])
+	}
+	lowest := windows[0]
+	for _, w := range windows {
+		if w.Score < lowest.Score {
+			lowest = w
+		}
+	}
+	// the spike changes the differences at points 70 and 71
+	if lowest.Index < 70 || lowest.Index > 74 || lowest.Label != 1 {
+		t.Errorf("Detector.Score() lowest window = %+v, want one ending at 70 to 74", lowest)
+	}
+
+	points, err := d.ScorePoints(s)
+	if err != nil {
+		t.Fatalf("Detector.ScorePoints() error = %v", err)
+	}
+	if len(points) != 24*5-22 || !points[0].Time.Equal(s.Times[22]) {
+		t.Fatalf("Detector.ScorePoints() = %v points, first at %v", len(points), points[0].Time)
+	}
+	if p := points[71-22]; p.Score != lowest.Score || p.Label != 1 {
+		t.Errorf("Detector.ScorePoints() spike = %+v, want score %v", p, lowest.Score)
+	}
+
+	// the detector is saved with its forest, the stream scores the same
+	// windows with a stride
+	path := filepath.Join(t.TempDir(), "detector.json")
+	if err := d.Save(path); err != nil {
+		t.Fatal(err)
+	}
+	loaded := &Detector{}
+	if err := loaded.Load(path); err != nil {
+		t.Fatal(err)
+	}
+	loaded.Stride = 5
+	stream, err := loaded.NewStream()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var streamed []Window
+	for i := range s.Times {
+		w, ok, err := stream.Push(s.Times[i], s.Values[i])
+		if err != nil {
+			t.Fatalf("Stream.Push() error = %v", err)
+		}
+		if ok {
+			streamed = append(streamed, w)
+		}
+	}
+	var want []Window
+	for i := 0; i < len(windows); i += 5 {
+		want = append(want, windows[i])
+	}
+	if !reflect.DeepEqual(streamed, want) {
+		t.Errorf("Stream.Push() = %v windows, want %v", len(streamed), len(want))
+	}
+	strided, _ := loaded.Score(s)
+	if !reflect.DeepEqual(strided, want) {
+		t.Errorf("Detector.Score() with a stride = %v windows, want %v", len(strided), len(want))
+	}
+	// one point out of five belongs to no window
+	if points, _ := loaded.ScorePoints(s); len(points) != 4*len(want) {
+		t.Errorf("Detector.ScorePoints() with a stride = %v points, want %v", len(points), 4*len(want))
+	}
+
+	if _, _, err := stream.Push(time.Now(), []float64{1, 2}); err == nil {
+		t.Errorf("Stream.Push() with two variables error = nil, want an error")
+	}
+	if _, err := d.Score(Series{Times: s.Times[:3], Values: s.Values}); err == nil {
+		t.Errorf("Detector.Score() with missing times error = nil, want an error")
+	}
+}
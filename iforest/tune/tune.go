@@ -0,0 +1,375 @@
+// Package tune searches the parameters of an isolation forest by training and
+// evaluating it on splits of a dataset.
+//
+// With ground truth labels (1 for anomalies, 0 for normal vectors), forests
+// are rated by a detection metric on the validation data. Without labels, they
+// are rated by the stability of their scores: the rank correlation between the
+// validation scores of two forests trained with different seeds.
+//
+// Forests built by this package can only vary by the parameters exposed by
+// iforest.Forest: the number of trees, the subsampling size, the anomaly
+// ratio and the seed.
+package tune
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"math/rand"
+	"sort"
+	"text/tabwriter"
+
+	"github.com/e-XpertSolutions/go-iforest/v2/iforest"
+	"github.com/e-XpertSolutions/go-iforest/v2/iforest/eval"
+)
+
+// Config is a set of forest parameters.
+type Config struct {
+	NbTrees         int
+	SubsamplingSize int
+	AnomalyRatio    float64
+	Seed            int64
+}
+
+// NewForest initializes a forest with the parameters of the configuration.
+func (c Config) NewForest() *iforest.Forest {
+	f := iforest.NewForest(c.NbTrees, c.SubsamplingSize, c.AnomalyRatio)
+	f.Seed = c.Seed
+	return f
+}
+
+// Grid lists the values tried for each parameter. Empty lists are replaced by
+// the default value of the parameter: 100 trees, subsampling size of 256,
+// anomaly ratio of 0.01 and seed 1.
+type Grid struct {
+	NbTrees          []int
+	SubsamplingSizes []int
+	AnomalyRatios    []float64
+	Seeds            []int64
+}
+
+// Configs returns every combination of the values of the grid.
+func (g Grid) Configs() []Config {
+	nbTrees, sizes, ratios, seeds := g.NbTrees, g.SubsamplingSizes, g.AnomalyRatios, g.Seeds
+	if len(nbTrees) == 0 {
+		nbTrees = []int{100}
+	}
+	if len(sizes) == 0 {
+		sizes = []int{256}
+	}
+	if len(ratios) == 0 {
+		ratios = []float64{0.01}
+	}
+	if len(seeds) == 0 {
+		seeds = []int64{1}
+	}
+
+	var configs []Config
+	for _, n := range nbTrees {
+		for _, size := range sizes {
+			for _, r := range ratios {
+				for _, seed := range seeds {
+					configs = append(configs, Config{NbTrees: n, SubsamplingSize: size, AnomalyRatio: r, Seed: seed})
+				}
+			}
+		}
+	}
+	return configs
+}
+
+// Metric is the measure used to rate a forest, higher is better.
+type Metric string
+
+// Metrics.
+const (
+	// AUC is the area under the ROC curve of the validation scores.
+	AUC Metric = "auc"
+	// AveragePrecision is the average precision of the validation scores.
+	AveragePrecision Metric = "ap"
+	// F1 is the F1 score of the validation labels predicted with the anomaly
+	// bound chosen on the training data.
+	F1 Metric = "f1"
+	// Stability is the Spearman's rank correlation between the validation
+	// scores of the forest and of a forest trained with another seed, derived
+	// from the seed of the configuration. It does not need labels.
+	Stability Metric = "stability"
+)
+
+// Options controls the search.
+type Options struct {
+	// Metric rates the forests. It defaults to AUC when labels are given and
+	// to Stability otherwise.
+	Metric Metric
+	// Folds is the number of folds of the cross-validation. When lower than
+	// 2, a single holdout split is used instead.
+	Folds int
+	// Holdout is the ratio of the data used for validation with a holdout
+	// split, 0.25 by default.
+	Holdout float64
+	// Samples turns the grid search into a random search over the given
+	// number of configurations drawn from the grid.
+	Samples int
+	// Seed drives the splits and the random search.
+	Seed int64
+}
+
+// Result is the evaluation of a configuration.
+type Result struct {
+	Config Config
+	// Mean and Std are the mean and the standard deviation of the metric over
+	// the splits.
+	Mean   float64
+	Std    float64
+	Scores []float64
+}
+
+// Search evaluates the configurations of the grid on splits of the dataset and
+// returns the best one with the results of all of them, sorted from the best
+// to the worst. Labels may be nil when the ground truth is unknown; splits are
+// stratified otherwise.
+func Search(X [][]float64, labels []int, grid Grid, opts Options) (Config, []Result, error) {
+	if len(X) == 0 {
+		return Config{}, nil, errors.New("cannot search - no data")
+	}
+	if labels != nil && len(labels) != len(X) {
+		return Config{}, nil, errors.New("cannot search - data and labels lengths differ")
+	}
+	metric := opts.Metric
+	if metric == "" {
+		metric = Stability
+		if labels != nil {
+			metric = AUC
+		}
+	}
+	if metric != Stability && labels == nil {
+		return Config{}, nil, fmt.Errorf("cannot search - metric %q needs labels", metric)
+	}
+
+	rnd := rand.New(rand.NewSource(opts.Seed))
+	splits, err := makeSplits(len(X), labels, opts, rnd)
+	if err != nil {
+		return Config{}, nil, err
+	}
+	if metric != Stability {
+		if err := checkClasses(splits, labels); err != nil {
+			return Config{}, nil, err
+		}
+	}
+
+	configs := grid.Configs()
+	if opts.Samples > 0 && opts.Samples < len(configs) {
+		rnd.Shuffle(len(configs), func(i, j int) {
+			configs[i], configs[j] = configs[j], configs[i]
+		})
+		configs = configs[:opts.Samples]
+	}
+
+	results := make([]Result, 0, len(configs))
+	for _, c := range configs {
+		if err := check(c, splits); err != nil {
+			return Config{}, nil, err
+		}
+		r := Result{Config: c}
+		for _, s := range splits {
+			score, err := evaluate(c, X, labels, s, metric)
+			if err != nil {
+				return Config{}, nil, err
+			}
+			r.Scores = append(r.Scores, score)
+		}
+		r.Mean, r.Std = meanStd(r.Scores)
+		results = append(results, r)
+	}
+
+	sort.SliceStable(results, func(i, j int) bool {
+		return results[i].Mean > results[j].Mean
+	})
+	return results[0].Config, results, nil
+}
+
+// WriteTable writes the results as an aligned text table.
+func WriteTable(w io.Writer, results []Result) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "trees\tsubsampling\tratio\tseed\tmean\tstd")
+	for _, r := range results {
+		fmt.Fprintf(tw, "%d\t%d\t%g\t%d\t%.4f\t%.4f\n", r.Config.NbTrees, r.Config.SubsamplingSize,
+			r.Config.AnomalyRatio, r.Config.Seed, r.Mean, r.Std)
+	}
+	return tw.Flush()
+}
+
+// split holds the indices of training and validation vectors.
+type split struct {
+	train      []int
+	validation []int
+}
+
+// makeSplits creates k folds or a single holdout split. Anomalies and normal
+// vectors are dealt separately so that each validation set gets its share of
+// both.
+func makeSplits(n int, labels []int, opts Options, rnd *rand.Rand) ([]split, error) {
+	groups := [][]int{nil, nil}
+	for i := 0; i < n; i++ {
+		g := 0
+		if labels != nil && labels[i] == 1 {
+			g = 1
+		}
+		groups[g] = append(groups[g], i)
+	}
+
+	folds := opts.Folds
+	if folds < 2 {
+		holdout := opts.Holdout
+		if holdout == 0 {
+			holdout = 0.25
+		}
+		if holdout <= 0 || holdout >= 1 {
+			return nil, errors.New("cannot search - holdout ratio must be between 0 and 1")
+		}
+		var s split
+		for _, g := range groups {
+			rnd.Shuffle(len(g), func(i, j int) { g[i], g[j] = g[j], g[i] })
+			cut := int(math.Round(holdout * float64(len(g))))
+			s.validation = append(s.validation, g[:cut]...)
+			s.train = append(s.train, g[cut:]...)
+		}
+		return []split{s}, nil
+	}
+
+	if folds > n {
+		return nil, errors.New("cannot search - more folds than vectors")
+	}
+	fold := make([]int, n)
+	next := 0
+	for _, g := range groups {
+		rnd.Shuffle(len(g), func(i, j int) { g[i], g[j] = g[j], g[i] })
+		for _, id := range g {
+			fold[id] = next % folds
+			next++
+		}
+	}
+	splits := make([]split, folds)
+	for id, k := range fold {
+		for j := range splits {
+			if j == k {
+				splits[j].validation = append(splits[j].validation, id)
+			} else {
+				splits[j].train = append(splits[j].train, id)
+			}
+		}
+	}
+	return splits, nil
+}
+
+func check(c Config, splits []split) error {
+	if c.NbTrees <= 0 || c.SubsamplingSize < 2 {
+		return fmt.Errorf("cannot search - invalid configuration %+v", c)
+	}
+	for _, s := range splits {
+		if c.SubsamplingSize > len(s.train) {
+			return fmt.Errorf("cannot search - subsampling size %d is larger than the %d training vectors", c.SubsamplingSize, len(s.train))
+		}
+		if len(s.validation) < 2 {
+			return errors.New("cannot search - validation sets are too small")
+		}
+	}
+	return nil
+}
+
+// checkClasses verifies that each validation set holds anomalies and normal
+// vectors, which the metrics based on labels need.
+func checkClasses(splits []split, labels []int) error {
+	for k, s := range splits {
+		var anomalies int
+		for _, id := range s.validation {
+			if labels[id] == 1 {
+				anomalies++
+			}
+		}
+		if anomalies == 0 {
+			return fmt.Errorf("cannot search - validation set %d has no anomaly, there must be at least one anomaly per fold", k+1)
+		}
+		if anomalies == len(s.validation) {
+			return fmt.Errorf("cannot search - validation set %d has only anomalies", k+1)
+		}
+	}
+	return nil
+}
+
+// evaluate trains a forest with given configuration on the training vectors
+// and rates it on the validation ones.
+func evaluate(c Config, X [][]float64, labels []int, s split, metric Metric) (float64, error) {
+	train := rows(X, s.train)
+	validation := rows(X, s.validation)
+
+	f := c.NewForest()
+	f.Train(train)
+	if err := f.Test(train); err != nil {
+		return 0, err
+	}
+	_, scores, err := f.Predict(validation)
+	if err != nil {
+		return 0, err
+	}
+
+	if metric == Stability {
+		other := c
+		other.Seed = otherSeed(c.Seed)
+		g := other.NewForest()
+		g.Train(train)
+		if err := g.Test(train); err != nil {
+			return 0, err
+		}
+		_, otherScores, err := g.Predict(validation)
+		if err != nil {
+			return 0, err
+		}
+		return eval.Spearman(scores, otherScores)
+	}
+
+	truth := make([]int, len(s.validation))
+	for i, id := range s.validation {
+		truth[i] = labels[id]
+	}
+	switch metric {
+	case AUC:
+		return eval.AUC(scores, truth)
+	case AveragePrecision:
+		return eval.AveragePrecision(scores, truth)
+	case F1:
+		m, err := eval.Confusion(scores, truth, f.AnomalyBound)
+		return m.F1(), err
+	}
+	return 0, fmt.Errorf("cannot search - unknown metric %q", metric)
+}
+
+// otherSeed derives the seed of the forest compared with a forest of given
+// seed by the Stability metric. It is never 0, which would make the training
+// use the top-level functions of math/rand.
+func otherSeed(seed int64) int64 {
+	return rand.New(rand.NewSource(seed)).Int63() | 1
+}
+
+func rows(X [][]float64, ids []int) [][]float64 {
+	subset := make([][]float64, len(ids))
+	for i, id := range ids {
+		subset[i] = X[id]
+	}
+	return subset
+}
+
+func meanStd(values []float64) (float64, float64) {
+	var mean, variance float64
+	for _, v := range values {
+		mean += v
+	}
+	mean /= float64(len(values))
+	for _, v := range values {
+		variance += (v - mean) * (v - mean)
+	}
+	if len(values) > 1 {
+		variance /= float64(len(values) - 1)
+	}
+	return mean, math.Sqrt(variance)
+}
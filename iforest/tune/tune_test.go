@@ -0,0 +1,140 @@
+package tune
+
+import (
+	"bytes"
+	"math/rand"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// dataset returns normal vectors around the origin with a few far anomalies.
+func dataset(n int) ([][]float64, []int) {
+	r := rand.New(rand.NewSource(1))
+	X := make([][]float64, n)
+	labels := make([]int, n)
+	for i := range X {
+		X[i] = []float64{r.NormFloat64(), r.NormFloat64(), r.NormFloat64()}
+		if i%20 == 0 {
+			X[i][r.Intn(3)] += 8
+			labels[i] = 1
+		}
+	}
+	return X, labels
+}
+
+func TestGrid_Configs(t *testing.T) {
+	g := Grid{NbTrees: []int{10, 20}, SubsamplingSizes: []int{16, 32, 64}}
+	configs := g.Configs()
+	if len(configs) != 6 {
+		t.Fatalf("Grid.Configs() = %v configurations, want 6", len(configs))
+	}
+	for _, c := range configs {
+		if c.AnomalyRatio != 0.01 || c.Seed != 1 {
+			t.Errorf("Grid.Configs() = %+v, want default ratio and seed", c)
+		}
+	}
+	if got := (Grid{}).Configs(); !reflect.DeepEqual(got, []Config{{NbTrees: 100, SubsamplingSize: 256, AnomalyRatio: 0.01, Seed: 1}}) {
+		t.Errorf("Grid.Configs() = %v, want the default configuration", got)
+	}
+}
+
+func TestSearch(t *testing.T) {
+	X, labels := dataset(400)
+	grid := Grid{NbTrees: []int{1, 50}, SubsamplingSizes: []int{64}, AnomalyRatios: []float64{0.05}}
+
+	tests := []struct {
+		name   string
+		labels []int
+		opts   Options
+		want   int
+	}{
+		{name: "auc", labels: labels, opts: Options{Folds: 3, Seed: 1}, want: 3},
+		{name: "ap", labels: labels, opts: Options{Metric: AveragePrecision, Seed: 1}, want: 1},
+		{name: "f1", labels: labels, opts: Options{Metric: F1, Holdout: 0.5, Seed: 1}, want: 1},
+		{name: "stability", labels: nil, opts: Options{Folds: 2, Seed: 1}, want: 2},
+	}
+	for _, tt := range tests {
+
+		best, results, err := Search(X, tt.labels, grid, tt.opts)
+		if err != nil {
+			t.Errorf("%s: Search() error = %v", tt.name, err)
+			continue
+		}
+		if len(results) != 2 || len(results[0].Scores) != tt.want {
+			t.Errorf("%s: Search() = %v results with %v scores, want 2 with %v", tt.name, len(results), len(results[0].Scores), tt.want)
+			continue
+		}
+		if best != results[0].Config || results[0].Mean < results[1].Mean {
+			t.Errorf("%s: Search() best = %+v, results = %+v", tt.name, best, results)
+		}
+		if best.NbTrees != 50 {
+			t.Errorf("%s: Search() best = %+v, want 50 trees", tt.name, best)
+		}
+
+	}
+}
+
+func TestSearch_Random(t *testing.T) {
+	X, labels := dataset(200)
+	grid := Grid{NbTrees: []int{10, 20, 30}, SubsamplingSizes: []int{16, 32}, Seeds: []int64{1, 2}}
+
+	_, results, err := Search(X, labels, grid, Options{Samples: 4, Seed: 3})
+	if err != nil {
+		t.Fatalf("Search() error = %v", err)
+	}
+	if len(results) != 4 {
+		t.Errorf("Search() = %v results, want 4", len(results))
+	}
+
+	var buf bytes.Buffer
+	if err := WriteTable(&buf, results); err != nil {
+		t.Fatalf("WriteTable() error = %v", err)
+	}
+	if lines := strings.Count(buf.String(), "\n"); lines != 5 {
+		t.Errorf("WriteTable() wrote %v lines, want 5:\n%s", lines, buf.String())
+	}
+}
+
+func TestSearch_Errors(t *testing.T) {
+	X, labels := dataset(100)
+
+	tests := []struct {
+		name   string
+		X      [][]float64
+		labels []int
+		grid   Grid
+		opts   Options
+	}{
+		{name: "empty", X: nil, grid: Grid{SubsamplingSizes: []int{16}}},
+		{name: "lengths", X: X, labels: labels[:10], grid: Grid{SubsamplingSizes: []int{16}}},
+		{name: "no labels", X: X, grid: Grid{SubsamplingSizes: []int{16}}, opts: Options{Metric: AUC}},
+		{name: "subsampling", X: X, labels: labels, grid: Grid{SubsamplingSizes: []int{90}}},
+		{name: "holdout", X: X, labels: labels, grid: Grid{SubsamplingSizes: []int{16}}, opts: Options{Holdout: 1.5}},
+		{name: "folds", X: X, labels: labels, grid: Grid{SubsamplingSizes: []int{16}}, opts: Options{Folds: 101}},
+		{name: "fewer anomalies than folds", X: X, labels: labels, grid: Grid{SubsamplingSizes: []int{16}}, opts: Options{Folds: 10}},
+		{name: "no validation anomaly", X: X, labels: labels, grid: Grid{SubsamplingSizes: []int{16}}, opts: Options{Holdout: 0.05}},
+		{name: "metric", X: X, labels: labels, grid: Grid{SubsamplingSizes: []int{16}}, opts: Options{Metric: "accuracy"}},
+	}
+	for _, tt := range tests {
+
+		if _, _, err := Search(tt.X, tt.labels, tt.grid, tt.opts); err == nil {
+			t.Errorf("%s: Search() should fail", tt.name)
+		}
+
+	}
+}
+
+func TestSearch_StabilitySeed(t *testing.T) {
+	X, _ := dataset(200)
+	grid := Grid{NbTrees: []int{20}, SubsamplingSizes: []int{32}, Seeds: []int64{-1}}
+
+	_, first, err := Search(X, nil, grid, Options{Folds: 2, Seed: 1})
+	if err != nil {
+		t.Fatalf("Search() error = %v", err)
+	}
+	_, second, _ := Search(X, nil, grid, Options{Folds: 2, Seed: 1})
+	if !reflect.DeepEqual(first, second) {
+		t.Errorf("Search() with seed -1 = %+v then %+v, want the same results", first, second)
+	}
+}